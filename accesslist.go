@@ -7,12 +7,18 @@ import (
 	zond "github.com/theQRL/go-zond"
 	"github.com/theQRL/go-zond/common"
 	"github.com/theQRL/go-zond/core/types"
-	"github.com/theQRL/go-zond/rpc"
-	"github.com/theQRL/go-zond/zondclient/gzondclient"
+	"github.com/theQRL/tx-fuzz/mutator"
 )
 
 // CreateAccessList creates a new access list for a transaction via the eth_createAccessList.
-func CreateAccessList(client *rpc.Client, tx *types.Transaction, from common.Address) (*types.AccessList, error) {
+func CreateAccessList(backend Backend, tx *types.Transaction, from common.Address) (*types.AccessList, error) {
+	al, _, _, err := createAccessList(backend, tx, from)
+	return al, err
+}
+
+// createAccessList creates a new access list for a transaction and additionally returns
+// the gas used by the transaction with the list and the execution error if any.
+func createAccessList(backend Backend, tx *types.Transaction, from common.Address) (*types.AccessList, uint64, string, error) {
 	msg := zond.CallMsg{
 		From:       from,
 		To:         tx.To(),
@@ -23,89 +29,240 @@ func CreateAccessList(client *rpc.Client, tx *types.Transaction, from common.Add
 		Data:       tx.Data(),
 		AccessList: nil,
 	}
-	if client == nil {
-		return &types.AccessList{}, nil
+	if backend == nil {
+		return &types.AccessList{}, 0, "", nil
 	}
-	geth := gzondclient.New(client)
-	al, _, _, err := geth.CreateAccessList(context.Background(), msg)
-	return al, err
+	return backend.CreateAccessList(context.Background(), msg)
+}
+
+const (
+	maxRandomAddresses = 16 // maximum number of addresses added by a single mutation
+	maxRandomKeys      = 16 // maximum number of storage keys per address added by a single mutation
+	// maxAccessListSize is the encoded size of the huge access lists, it leaves
+	// room for the signature and the other fields below the tx size limit.
+	maxAccessListSize = maxDataPerTx - 16*1024
+)
+
+// AccessListMutator mutates access lists. All randomness is drawn from the
+// passed source, so mutations are reproducible.
+type AccessListMutator struct {
+	r *rand.Rand
 }
 
-type accessListMutator func(list *types.AccessList) *types.AccessList
+// NewAccessListMutator creates a new access list mutator.
+func NewAccessListMutator(r *rand.Rand) *AccessListMutator {
+	return &AccessListMutator{r: r}
+}
+
+type accessListMutator func(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList
 
 var mutators = []accessListMutator{
 	noChange,
-	delete,
+	emptyList,
 	addRandom,
+	removeRandom,
 	replaceRandom,
 	replaceRandomSlot,
 	fullyRandom,
+	duplicateAddress,
+	duplicateKey,
+	addPrecompile,
+	addInvolved,
+	hugeList,
 }
 
 // MutateAccessList mutates the given access list.
 func MutateAccessList(list types.AccessList) *types.AccessList {
-	index := rand.Intn(len(mutators))
-	return mutators[index](&list)
+	m := NewAccessListMutator(rand.New(rand.NewSource(rand.Int63())))
+	mutated := m.Mutate(list)
+	return &mutated
+}
+
+// Mutate applies a random mutation to a copy of list. The addresses involved in
+// the transaction, e.g. sender, recipient and coinbase, are added to the list
+// by some of the mutators. Mutations that would grow the list beyond the size
+// limit are dropped.
+func (m *AccessListMutator) Mutate(list types.AccessList, involved ...common.Address) types.AccessList {
+	mut := mutators[m.r.Intn(len(mutators))]
+	mutated := mut(m, copyAccessList(list), involved)
+	if accessListSize(mutated) > max(maxAccessListSize, accessListSize(list)) {
+		return copyAccessList(list)
+	}
+	return mutated
 }
 
 // Leave the accesslist as is
-func noChange(list *types.AccessList) *types.AccessList { return list }
+func noChange(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	return list
+}
 
 // empty the access list
-func delete(list *types.AccessList) *types.AccessList { return &types.AccessList{} }
+func emptyList(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	return types.AccessList{}
+}
 
 // add a random entry and random slots to the list
-func addRandom(list *types.AccessList) *types.AccessList {
-	addr := randomAddress()
-	keys := []common.Hash{}
-	for i := 0; i < rand.Intn(10); i++ {
-		h := randomHash()
-		keys = append(keys, h)
+func addRandom(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	return m.insert(list, types.AccessTuple{Address: m.address(), StorageKeys: m.keys(maxRandomKeys)})
+}
+
+// remove a random entry from the list
+func removeRandom(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(list) == 0 {
+		return list
 	}
-	tuple := types.AccessTuple{Address: addr, StorageKeys: keys}
-	newList := types.AccessList(append([]types.AccessTuple{tuple}, *list...))
-	return &newList
+	i := m.r.Intn(len(list))
+	return append(list[:i], list[i+1:]...)
 }
 
 // replace a random entry and random slots of it in the list
-func replaceRandom(list *types.AccessList) *types.AccessList {
-	slot := (*list)[rand.Int31n(int32(len(*list)))]
-	addr := randomAddress()
-	keys := []common.Hash{}
-	if len(slot.StorageKeys) == 0 {
+func replaceRandom(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(list) == 0 {
+		return addRandom(m, list, involved)
+	}
+	i := m.r.Intn(len(list))
+	list[i] = types.AccessTuple{Address: m.address(), StorageKeys: m.keys(len(list[i].StorageKeys))}
+	return list
+}
+
+// replace a random slot in an existing entry
+func replaceRandomSlot(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(list) == 0 {
 		return list
 	}
-	for i := 0; i < rand.Intn(len(slot.StorageKeys)); i++ {
-		h := randomHash()
-		keys = append(keys, h)
+	tuple := list[m.r.Intn(len(list))]
+	if len(tuple.StorageKeys) == 0 {
+		return list
 	}
-	tuple := types.AccessTuple{Address: addr, StorageKeys: keys}
-	newList := types.AccessList(append([]types.AccessTuple{tuple}, *list...))
-	return &newList
+	tuple.StorageKeys[m.r.Intn(len(tuple.StorageKeys))] = m.hash()
+	return list
 }
 
-// replace a random slot in an existing entry
-func replaceRandomSlot(list *types.AccessList) *types.AccessList {
-	keyIdx := rand.Int31n(int32(len(*list)))
-	slotIdx := rand.Int31n(int32(len((*list)[keyIdx].StorageKeys)))
-	h := randomHash()
-	(*list)[keyIdx].StorageKeys[slotIdx] = h
+// create a fully random access list
+func fullyRandom(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	accesslist := make(types.AccessList, m.r.Intn(maxRandomAddresses+1))
+	for i := range accesslist {
+		accesslist[i] = types.AccessTuple{Address: m.address(), StorageKeys: m.keys(maxRandomKeys)}
+	}
+	return accesslist
+}
+
+// add an entry with the address of an existing entry, with either the same or random slots
+func duplicateAddress(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(list) == 0 {
+		return addRandom(m, list, involved)
+	}
+	tuple := list[m.r.Intn(len(list))]
+	keys := append([]common.Hash{}, tuple.StorageKeys...)
+	if m.r.Intn(2) == 0 {
+		keys = m.keys(maxRandomKeys)
+	}
+	return m.insert(list, types.AccessTuple{Address: tuple.Address, StorageKeys: keys})
+}
+
+// add a slot of an entry to the entry again
+func duplicateKey(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(list) == 0 {
+		return list
+	}
+	i := m.r.Intn(len(list))
+	keys := list[i].StorageKeys
+	if len(keys) == 0 {
+		keys = append(keys, m.hash())
+	}
+	key := keys[m.r.Intn(len(keys))]
+	for n := m.r.Intn(3) + 1; n > 0; n-- {
+		pos := m.r.Intn(len(keys) + 1)
+		keys = append(keys[:pos], append([]common.Hash{key}, keys[pos:]...)...)
+	}
+	list[i].StorageKeys = keys
+	return list
+}
+
+// add a precompile, which is always warm
+func addPrecompile(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	precompiles := mutator.Precompiles()
+	addr := precompiles[m.r.Intn(len(precompiles))]
+	return m.insert(list, types.AccessTuple{Address: addr, StorageKeys: m.keys(2)})
+}
+
+// add an address involved in the transaction, which is always warm
+func addInvolved(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	if len(involved) == 0 {
+		return addRandom(m, list, involved)
+	}
+	addr := involved[m.r.Intn(len(involved))]
+	return m.insert(list, types.AccessTuple{Address: addr, StorageKeys: m.keys(maxRandomKeys)})
+}
+
+// add so many slots that the transaction is close to the size limit
+func hugeList(m *AccessListMutator, list types.AccessList, involved []common.Address) types.AccessList {
+	addrs := m.r.Intn(maxRandomAddresses) + 1
+	list = make(types.AccessList, addrs)
+	for i := range list {
+		list[i] = types.AccessTuple{Address: m.address(), StorageKeys: []common.Hash{}}
+	}
+	keys := (maxAccessListSize - accessListSize(list)) / (common.HashLength + 1)
+	for ; keys > 0; keys-- {
+		tuple := &list[m.r.Intn(addrs)]
+		tuple.StorageKeys = append(tuple.StorageKeys, m.hash())
+	}
 	return list
 }
 
-func fullyRandom(list *types.AccessList) *types.AccessList {
-	var accesslist []types.AccessTuple
-	for i := 0; i < rand.Int(); i++ {
-		addr := randomAddress()
-		keys := []common.Hash{}
-		// create a fully random access list
-		for q := 0; q < rand.Int(); q++ {
-			h := randomHash()
-			keys = append(keys, h)
+// insert adds the tuple at a random position.
+func (m *AccessListMutator) insert(list types.AccessList, tuple types.AccessTuple) types.AccessList {
+	pos := m.r.Intn(len(list) + 1)
+	return append(list[:pos], append(types.AccessList{tuple}, list[pos:]...)...)
+}
+
+func (m *AccessListMutator) address() common.Address {
+	var addr common.Address
+	switch m.r.Intn(5) {
+	case 0, 1, 2:
+		m.r.Read(addr[:])
+	case 3:
+		// zero address
+	case 4:
+		addr, _ = common.NewAddressFromString(ADDR)
+	}
+	return addr
+}
+
+func (m *AccessListMutator) hash() common.Hash {
+	var h common.Hash
+	switch m.r.Intn(4) {
+	case 0:
+		// low slots are the ones used by most contracts
+		h[common.HashLength-1] = byte(m.r.Intn(4))
+	default:
+		m.r.Read(h[:])
+	}
+	return h
+}
+
+// keys returns up to n random storage keys.
+func (m *AccessListMutator) keys(n int) []common.Hash {
+	keys := make([]common.Hash, m.r.Intn(n+1))
+	for i := range keys {
+		keys[i] = m.hash()
+	}
+	return keys
+}
+
+// accessListSize is an upper bound of the encoded size of the list. Every address takes
+// 21 bytes and every storage key 33 bytes, plus the headers of the lists.
+func accessListSize(list types.AccessList) int {
+	return len(list)*(common.AddressLength+8) + list.StorageKeys()*(common.HashLength+1)
+}
+
+func copyAccessList(list types.AccessList) types.AccessList {
+	cpy := make(types.AccessList, len(list))
+	for i, tuple := range list {
+		cpy[i] = types.AccessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]common.Hash{}, tuple.StorageKeys...),
 		}
-		tuple := types.AccessTuple{Address: addr, StorageKeys: keys}
-		accesslist = append(accesslist, tuple)
 	}
-	newList := types.AccessList(accesslist)
-	return &newList
+	return cpy
 }
@@ -0,0 +1,352 @@
+package txfuzz
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/params"
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+// AccessListChecker compares the gas used by transactions with a mutated access list
+// against the gas the node reported for the unmutated list. If the mutated list
+// contains the unmutated one and the execution gets the same gas, it costs the
+// same and the gas used only differs by the intrinsic gas of the additional
+// entries, less the part covered by a capped refund.
+type AccessListChecker struct {
+	mu       sync.Mutex
+	expected map[estimateKey]accessListExpectation
+	stats    AccessListStats
+}
+
+type accessListExpectation struct {
+	original types.AccessList
+	gasUsed  uint64 // gas used with the original list as reported by zond_createAccessList
+}
+
+// AccessListStats are the statistics of an AccessListChecker.
+type AccessListStats struct {
+	Mutated   uint64 // transactions sent with a mutated access list
+	Observed  uint64 // receipts of transactions with a mutated access list
+	Compared  uint64 // receipts compared against the unmutated list
+	Disagreed uint64 // receipts where gasUsed differed from the expectation
+}
+
+func (s AccessListStats) String() string {
+	return fmt.Sprintf("access lists: mutated: %v observed: %v compared: %v disagreed: %v", s.Mutated, s.Observed, s.Compared, s.Disagreed)
+}
+
+// NewAccessListChecker creates a new access list checker.
+func NewAccessListChecker() *AccessListChecker {
+	return &AccessListChecker{
+		expected: make(map[estimateKey]accessListExpectation),
+	}
+}
+
+// expect records the unmutated list and its gas used for the transaction of sender with nonce.
+func (c *AccessListChecker) expect(sender common.Address, nonce uint64, original types.AccessList, gasUsed uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Mutated++
+	c.expected[estimateKey{sender, nonce}] = accessListExpectation{original: original, gasUsed: gasUsed}
+}
+
+// Observe compares the gas used by an included transaction of sender against the
+// gas used with the unmutated list. It returns the expected gas used and false if
+// the node charged more, or less than a capped refund allows.
+func (c *AccessListChecker) Observe(sender common.Address, tx *types.Transaction, receipt *types.Receipt) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := estimateKey{sender, tx.Nonce()}
+	exp, ok := c.expected[key]
+	if !ok {
+		return 0, true
+	}
+	delete(c.expected, key)
+	c.stats.Observed++
+	// Only successful transactions of which the mutated list covers the original
+	// one are comparable, everything else legitimately changes the execution.
+	if receipt.Status != types.ReceiptStatusSuccessful || !containsAccessList(tx.AccessList(), exp.original) {
+		return 0, true
+	}
+	c.stats.Compared++
+	// The refund is capped to a fifth of the gas used before the refund. If the cap
+	// applied, the additional entries raise it and only part of them is charged.
+	extra := accessListGas(tx.AccessList()) - accessListGas(exp.original)
+	expected := exp.gasUsed + extra
+	if receipt.GasUsed > expected || receipt.GasUsed < expected-(extra+params.RefundQuotientEIP3529-1)/params.RefundQuotientEIP3529 {
+		c.stats.Disagreed++
+		return expected, false
+	}
+	return expected, true
+}
+
+// Stats returns a copy of the current statistics.
+func (c *AccessListChecker) Stats() AccessListStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// accessListGas returns the intrinsic gas charged for the access list.
+// Duplicate addresses and storage keys are charged every time they occur.
+func accessListGas(list types.AccessList) uint64 {
+	return uint64(len(list))*params.TxAccessListAddressGas + uint64(list.StorageKeys())*params.TxAccessListStorageKeyGas
+}
+
+// containsAccessList reports whether every address and storage key of sub is also in list.
+func containsAccessList(list, sub types.AccessList) bool {
+	type slot struct {
+		addr common.Address
+		key  common.Hash
+	}
+	var (
+		addrs = make(map[common.Address]struct{})
+		slots = make(map[slot]struct{})
+	)
+	for _, tuple := range list {
+		addrs[tuple.Address] = struct{}{}
+		for _, key := range tuple.StorageKeys {
+			slots[slot{tuple.Address, key}] = struct{}{}
+		}
+	}
+	for _, tuple := range sub {
+		if _, ok := addrs[tuple.Address]; !ok {
+			return false
+		}
+		for _, key := range tuple.StorageKeys {
+			if _, ok := slots[slot{tuple.Address, key}]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AccessListDivergence is a difference between the gas a node charged for a
+// transaction with an access list and the gas it should have charged.
+type AccessListDivergence struct {
+	Tx     *types.Transaction
+	Reason string
+	Pc     uint64 // pc of the instruction, if the divergence is in the execution
+	Have   uint64 // gas charged by the node
+	Want   uint64 // gas that should have been charged
+}
+
+func (d *AccessListDivergence) String() string {
+	return fmt.Sprintf("%v: tx %v pc %v: charged %v, expected %v", d.Reason, d.Tx.Hash(), d.Pc, d.Have, d.Want)
+}
+
+// CheckAccessListGas verifies the gas accounting of an included transaction against
+// its access list. It checks that the intrinsic gas includes 2400 gas per address and
+// 1900 gas per storage key of the list and that the execution, as traced by the
+// struct logger with the stack enabled, charges accesses to listed addresses and
+// storage slots as warm. Calls and EXTCODECOPY that expand the memory are only
+// checked if the memory is traced as well.
+func CheckAccessListGas(tx *types.Transaction, sender, coinbase common.Address, receipt *types.Receipt, trace *logger.ExecutionResult) []*AccessListDivergence {
+	var divergences []*AccessListDivergence
+	report := func(reason string, pc, have, want uint64) {
+		divergences = append(divergences, &AccessListDivergence{Tx: tx, Reason: reason, Pc: pc, Have: have, Want: want})
+	}
+	intrinsic, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil)
+	if err != nil {
+		return nil
+	}
+	// The gas available to the first instruction is the gas limit minus the intrinsic
+	// gas. Transactions without execution only pay the intrinsic gas, except for calls
+	// to precompiles, which are not traced.
+	if len(trace.StructLogs) != 0 {
+		if have := tx.Gas() - trace.StructLogs[0].Gas; have != intrinsic {
+			report("intrinsic gas", 0, have, intrinsic)
+		}
+	} else if receipt.Status == types.ReceiptStatusSuccessful && receipt.GasUsed != intrinsic && !(tx.To() != nil && slices.Contains(mutator.Precompiles(), *tx.To())) {
+		report("intrinsic gas", 0, receipt.GasUsed, intrinsic)
+	}
+
+	// Collect everything that is warm from the start of the transaction
+	type slot struct {
+		addr common.Address
+		key  common.Hash
+	}
+	var (
+		warmAddrs = make(map[common.Address]bool)
+		warmSlots = make(map[slot]bool)
+	)
+	for _, tuple := range tx.AccessList() {
+		warmAddrs[tuple.Address] = true
+		for _, key := range tuple.StorageKeys {
+			warmSlots[slot{tuple.Address, key}] = true
+		}
+	}
+	for _, addr := range mutator.Precompiles() {
+		warmAddrs[addr] = true
+	}
+	warmAddrs[sender] = true
+	warmAddrs[coinbase] = true
+	toplevel := receipt.ContractAddress
+	if tx.To() != nil {
+		toplevel = *tx.To()
+	}
+	warmAddrs[toplevel] = true
+
+	// Follow the storage context of the call frames, nil if it is not known
+	var (
+		contexts = map[int]*common.Address{1: &toplevel}
+		next     *common.Address
+		depth    = 1
+	)
+	if tx.To() == nil && receipt.Status != types.ReceiptStatusSuccessful {
+		// The address of a failed creation is not part of the receipt
+		contexts[1] = nil
+	}
+	for _, log := range trace.StructLogs {
+		if log.Depth > depth {
+			contexts[log.Depth] = next
+		}
+		depth = log.Depth
+		if log.Stack == nil || log.Error != "" {
+			continue
+		}
+		stack := *log.Stack
+		peek := func(n int) common.Hash {
+			return common.HexToHash(stack[len(stack)-1-n])
+		}
+		switch vm.StringToOp(log.Op) {
+		case vm.SLOAD:
+			if len(stack) < 1 || contexts[depth] == nil {
+				continue
+			}
+			if warmSlots[slot{*contexts[depth], peek(0)}] && log.GasCost != params.WarmStorageReadCostEIP2929 {
+				report("listed storage slot charged cold", log.Pc, log.GasCost, params.WarmStorageReadCostEIP2929)
+			}
+		case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH:
+			if len(stack) < 1 {
+				continue
+			}
+			addr := common.BytesToAddress(peek(0).Bytes())
+			if warmAddrs[addr] && log.GasCost != params.WarmStorageReadCostEIP2929 {
+				report("warm address charged cold", log.Pc, log.GasCost, params.WarmStorageReadCostEIP2929)
+			}
+		case vm.EXTCODECOPY:
+			if len(stack) < 4 || !warmAddrs[common.BytesToAddress(peek(0).Bytes())] {
+				continue
+			}
+			mem, ok := memoryExpansion(log, peek(1), peek(3))
+			if !ok {
+				continue
+			}
+			want := params.WarmStorageReadCostEIP2929 + mem + toWordSize(peek(3).Big().Uint64())*params.CopyGas
+			if log.GasCost != want {
+				report("warm address charged cold", log.Pc, log.GasCost, want)
+			}
+		case vm.CALL, vm.DELEGATECALL, vm.STATICCALL:
+			// The memory ranges of the arguments and the return data follow the value of CALL
+			args := 2
+			if vm.StringToOp(log.Op) == vm.CALL {
+				args = 3
+			}
+			if len(stack) < args+4 {
+				continue
+			}
+			addr := common.BytesToAddress(peek(1).Bytes())
+			if vm.StringToOp(log.Op) == vm.DELEGATECALL {
+				// The callee runs on the storage of the caller
+				next = contexts[depth]
+			} else {
+				next = &addr
+			}
+			if !warmAddrs[addr] {
+				continue
+			}
+			mem, ok := memoryExpansion(log, peek(args), peek(args+1), peek(args+2), peek(args+3))
+			if !ok {
+				continue
+			}
+			bases := []uint64{mem}
+			if args == 3 && peek(2) != (common.Hash{}) {
+				// Whether the value is sent to a new account is not traced
+				bases = []uint64{mem + params.CallValueTransferGas, mem + params.CallValueTransferGas + params.CallNewAccountGas}
+			}
+			// Report the highest cost below the charged one
+			want := warmCallCost(log.Gas, bases[0], peek(0))
+			for _, base := range bases {
+				cost := warmCallCost(log.Gas, base, peek(0))
+				if cost == log.GasCost {
+					want = cost
+					break
+				}
+				if cost < log.GasCost {
+					want = cost
+				}
+			}
+			if log.GasCost != want {
+				report("warm address charged cold", log.Pc, log.GasCost, want)
+			}
+		case vm.CREATE, vm.CREATE2:
+			next = nil
+		}
+	}
+	return divergences
+}
+
+// warmCallCost returns the cost of a call to a warm address with the given gas available
+// before the call, the cost of everything but the gas passed on and the requested gas.
+func warmCallCost(gas, base uint64, requested common.Hash) uint64 {
+	if gas < params.WarmStorageReadCostEIP2929+base {
+		return params.WarmStorageReadCostEIP2929 + base
+	}
+	// All but one 64th of the remaining gas is passed on at most
+	available := gas - params.WarmStorageReadCostEIP2929 - base
+	callGas := available - available/64
+	if req := requested.Big(); req.IsUint64() && req.Uint64() < callGas {
+		callGas = req.Uint64()
+	}
+	return params.WarmStorageReadCostEIP2929 + base + callGas
+}
+
+// memoryExpansion returns the gas to expand the memory of the traced instruction to cover
+// the given pairs of offset and length. It returns false if the memory of the instruction
+// is not traced but needs to be expanded.
+func memoryExpansion(log logger.StructLogRes, ranges ...common.Hash) (uint64, bool) {
+	var size uint64
+	for i := 0; i+1 < len(ranges); i += 2 {
+		offset, length := ranges[i].Big(), ranges[i+1].Big()
+		if length.Sign() == 0 {
+			continue
+		}
+		end := new(big.Int).Add(offset, length)
+		if !end.IsUint64() {
+			return 0, false
+		}
+		size = max(size, end.Uint64())
+	}
+	if size == 0 {
+		return 0, true
+	}
+	if log.Memory == nil {
+		return 0, false
+	}
+	current := toWordSize(uint64(len(*log.Memory)) * 32)
+	words := toWordSize(size)
+	if words <= current {
+		return 0, true
+	}
+	return memoryGas(words) - memoryGas(current), true
+}
+
+// memoryGas returns the total cost of memory of the given number of words.
+func memoryGas(words uint64) uint64 {
+	return words*params.MemoryGas + words*words/params.QuadCoeffDiv
+}
+
+// toWordSize returns the number of 32 byte words needed to hold size bytes.
+func toWordSize(size uint64) uint64 {
+	return (size + 31) / 32
+}
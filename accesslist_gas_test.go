@@ -0,0 +1,228 @@
+package txfuzz
+
+import (
+	"encoding/json"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/hexutil"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/rawdb"
+	"github.com/theQRL/go-zond/core/state"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/core/vm/runtime"
+	"github.com/theQRL/go-zond/params"
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+	"github.com/theQRL/gozvmlab/ops"
+	"github.com/theQRL/gozvmlab/program"
+)
+
+func TestAccessListChecker(t *testing.T) {
+	var (
+		sender   = common.Address{1}
+		original = types.AccessList{{Address: common.Address{2}, StorageKeys: []common.Hash{{1}}}}
+		superset = types.AccessList{
+			{Address: common.Address{2}, StorageKeys: []common.Hash{{1}, {2}}},
+			{Address: common.Address{3}},
+		}
+		subset = types.AccessList{}
+	)
+	tests := []struct {
+		list    types.AccessList
+		status  uint64
+		gasUsed uint64
+		ok      bool
+	}{
+		// 2400 + 1900 for the additional address and key
+		{superset, types.ReceiptStatusSuccessful, 50_000 + 4300, true},
+		{superset, types.ReceiptStatusSuccessful, 50_000, false},
+		{superset, types.ReceiptStatusSuccessful, 50_000 + 4301, false},
+		// A capped refund grows by up to a fifth of the additional gas
+		{superset, types.ReceiptStatusSuccessful, 50_000 + 4300 - 860, true},
+		{superset, types.ReceiptStatusSuccessful, 50_000 + 4300 - 861, false},
+		// Removing entries changes the execution
+		{subset, types.ReceiptStatusSuccessful, 12345, true},
+		{superset, types.ReceiptStatusFailed, 12345, true},
+	}
+	for i, test := range tests {
+		c := NewAccessListChecker()
+		c.expect(sender, 1, original, 50_000)
+		tx := types.NewTx(&types.DynamicFeeTx{Nonce: 1, Gas: 100_000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), AccessList: test.list})
+		receipt := &types.Receipt{Status: test.status, GasUsed: test.gasUsed}
+		if _, ok := c.Observe(sender, tx, receipt); ok != test.ok {
+			t.Errorf("test %d: have %v want %v", i, ok, test.ok)
+		}
+		// Transactions are only observed once
+		if _, ok := c.Observe(sender, tx, receipt); !ok {
+			t.Errorf("test %d: observed twice", i)
+		}
+	}
+}
+
+func TestCheckAccessListGas(t *testing.T) {
+	var (
+		sender = common.Address{1}
+		to     = common.Address{2}
+		other  = common.Address{3}
+		list   = types.AccessList{
+			{Address: to, StorageKeys: []common.Hash{{}}},
+			{Address: other},
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			To:         &to,
+			Gas:        100_000,
+			GasFeeCap:  big.NewInt(1),
+			GasTipCap:  big.NewInt(1),
+			AccessList: list,
+		})
+		receipt   = &types.Receipt{Status: types.ReceiptStatusSuccessful}
+		intrinsic = params.TxGas + 2*params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas
+	)
+	stack := func(items ...string) *[]string { return &items }
+	trace := func(sloadCost, balanceCost uint64) *logger.ExecutionResult {
+		return &logger.ExecutionResult{StructLogs: []logger.StructLogRes{
+			{Pc: 0, Op: "PUSH0", Gas: tx.Gas() - intrinsic, GasCost: 2, Depth: 1, Stack: stack()},
+			{Pc: 1, Op: "SLOAD", GasCost: sloadCost, Depth: 1, Stack: stack("0x0")},
+			{Pc: 2, Op: "BALANCE", GasCost: balanceCost, Depth: 1, Stack: stack("0x0", hexutil.Encode(other[:]))},
+		}}
+	}
+	if d := CheckAccessListGas(tx, sender, common.Address{}, receipt, trace(100, 100)); len(d) != 0 {
+		t.Fatalf("unexpected divergences: %v", d)
+	}
+	d := CheckAccessListGas(tx, sender, common.Address{}, receipt, trace(2100, 2600))
+	if len(d) != 2 || d[0].Pc != 1 || d[1].Pc != 2 {
+		t.Fatalf("expected divergences at pc 1 and 2, got %v", d)
+	}
+	wrong := trace(100, 100)
+	wrong.StructLogs[0].Gas += params.TxAccessListStorageKeyGas
+	if d := CheckAccessListGas(tx, sender, common.Address{}, receipt, wrong); len(d) != 1 || d[0].Want != intrinsic {
+		t.Fatalf("expected intrinsic gas divergence, got %v", d)
+	}
+	// Transactions without execution only pay the intrinsic gas
+	empty := &logger.ExecutionResult{}
+	if d := CheckAccessListGas(tx, sender, common.Address{}, &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: intrinsic + 1}, empty); len(d) != 1 {
+		t.Fatalf("expected intrinsic gas divergence, got %v", d)
+	}
+	// Precompiles are not traced but charge for their execution
+	precompile := common.BytesToAddress([]byte{2})
+	call := types.NewTx(&types.DynamicFeeTx{To: &precompile, Gas: 100_000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)})
+	if d := CheckAccessListGas(call, sender, common.Address{}, &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: params.TxGas + 60}, empty); len(d) != 0 {
+		t.Fatalf("unexpected divergences: %v", d)
+	}
+}
+
+// traceCalls traces code calling and copying the code of other accounts, executed with
+// the access list warm, and returns it as a transaction with the access list list.
+func traceCalls(t *testing.T, list, warm types.AccessList) (*types.Transaction, *logger.ExecutionResult) {
+	t.Helper()
+	var (
+		origin   = common.Address{1}
+		contract = common.Address{2}
+		callee   = common.Address{3}
+		empty    = common.Address{4}
+		gas      = uint64(1_000_000)
+	)
+	p := program.NewProgram()
+	p.Mstore([]byte{1}, 0)
+	p.ExtcodeCopy(callee, 0x40, 0, 0x50)
+	p.ExtcodeCopy(callee, 0, 0, 0)
+	p.Call(nil, callee, 1, 0, 0x20, 0x100, 0x20)
+	// Sending value to an empty account creates it
+	p.Call(big.NewInt(1000), empty, 1, 0, 0, 0, 0)
+	p.StaticCall(nil, callee, 0, 0x20, 0, 0)
+	p.DelegateCall(big.NewInt(5000), callee, 0, 0, 0x300, 0x20)
+	p.Op(ops.STOP)
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.SetCode(contract, p.Bytecode())
+	statedb.SetBalance(contract, big.NewInt(10))
+	statedb.SetCode(callee, []byte{byte(vm.STOP)})
+	tracer := logger.NewStructLogger(&logger.Config{EnableMemory: true})
+	cfg := &runtime.Config{
+		ChainConfig: params.TestChainConfig,
+		Origin:      origin,
+		BlockNumber: new(big.Int),
+		GasLimit:    gas,
+		GasPrice:    new(big.Int),
+		BaseFee:     new(big.Int),
+		Random:      &common.Hash{},
+		State:       statedb,
+		ZVMConfig:   vm.Config{Tracer: tracer},
+	}
+	env := runtime.NewEnv(cfg)
+	rules := cfg.ChainConfig.Rules(cfg.BlockNumber, cfg.Time)
+	statedb.Prepare(rules, origin, common.Address{}, &contract, vm.ActivePrecompiles(rules), warm)
+	tracer.CaptureTxStart(gas)
+	tracer.CaptureStart(env, origin, contract, false, nil, gas, new(big.Int))
+	_, left, err := env.Call(vm.AccountRef(origin), contract, nil, gas, new(big.Int))
+	tracer.CaptureEnd(nil, gas-left, err)
+	tracer.CaptureTxEnd(left)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := new(logger.ExecutionResult)
+	if err := json.Unmarshal(res, trace); err != nil {
+		t.Fatal(err)
+	}
+	intrinsic, err := core.IntrinsicGas(nil, list, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		To:         &contract,
+		Gas:        gas + intrinsic,
+		GasFeeCap:  big.NewInt(1),
+		GasTipCap:  big.NewInt(1),
+		AccessList: list,
+	})
+	return tx, trace
+}
+
+func TestCheckAccessListGasCalls(t *testing.T) {
+	var (
+		sender  = common.Address{1}
+		receipt = &types.Receipt{Status: types.ReceiptStatusSuccessful}
+		list    = types.AccessList{{Address: common.Address{3}}, {Address: common.Address{4}}}
+	)
+	// Listed addresses are charged as warm
+	tx, trace := traceCalls(t, list, list)
+	if d := CheckAccessListGas(tx, sender, common.Address{}, receipt, trace); len(d) != 0 {
+		t.Fatalf("unexpected divergences: %v", d)
+	}
+	// A node charging listed addresses as cold diverges on every access
+	tx, trace = traceCalls(t, list, nil)
+	d := CheckAccessListGas(tx, sender, common.Address{}, receipt, trace)
+	var ops []string
+	for _, div := range d {
+		for _, log := range trace.StructLogs {
+			if log.Pc == div.Pc && log.Depth == 1 {
+				ops = append(ops, log.Op)
+			}
+		}
+		if div.Have != div.Want+params.ColdAccountAccessCostEIP2929-params.WarmStorageReadCostEIP2929 {
+			t.Errorf("%v at pc %v: charged %v, expected %v plus the cold surcharge", div.Reason, div.Pc, div.Have, div.Want)
+		}
+	}
+	// Only the first access of each address is cold
+	want := []string{"EXTCODECOPY", "CALL"}
+	if !slices.Equal(ops, want) {
+		t.Fatalf("divergences at %v, want %v", ops, want)
+	}
+	// Without the memory, only accesses that don't expand it are checked
+	for i := range trace.StructLogs {
+		trace.StructLogs[i].Memory = nil
+	}
+	if d := CheckAccessListGas(tx, sender, common.Address{}, receipt, trace); len(d) != 1 {
+		t.Fatalf("expected a single divergence, got %v", d)
+	}
+}
@@ -0,0 +1,229 @@
+package txfuzz
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	zond "github.com/theQRL/go-zond"
+	"github.com/theQRL/go-zond/accounts/abi/bind/backends"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/math"
+	"github.com/theQRL/go-zond/consensus/misc/eip1559"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/crypto"
+	"github.com/theQRL/go-zond/rpc"
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+	"github.com/theQRL/go-zond/zondclient"
+	"github.com/theQRL/go-zond/zondclient/gzondclient"
+)
+
+// Backend is the part of the node api needed to generate and send transactions.
+// It is implemented by a connection to a node, see NewRPCBackend, as well as by
+// an in-process chain, see NewSimulatedBackend.
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg zond.CallMsg) (uint64, error)
+	CallContract(ctx context.Context, msg zond.CallMsg, blockNumber *big.Int) ([]byte, error)
+	CreateAccessList(ctx context.Context, msg zond.CallMsg) (*types.AccessList, uint64, string, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+type rpcBackend struct {
+	*zondclient.Client
+	gzond *gzondclient.Client
+}
+
+// NewRPCBackend returns a backend that talks to a node via the rpc client.
+func NewRPCBackend(client *rpc.Client) Backend {
+	return &rpcBackend{
+		Client: zondclient.NewClient(client),
+		gzond:  gzondclient.New(client),
+	}
+}
+
+func (b *rpcBackend) CreateAccessList(ctx context.Context, msg zond.CallMsg) (*types.AccessList, uint64, string, error) {
+	return b.gzond.CreateAccessList(ctx, msg)
+}
+
+// SimulatedBackend is an in-process chain that mines a block for every transaction.
+// It allows running the generators and spammers without a node.
+type SimulatedBackend struct {
+	*backends.SimulatedBackend
+	mu sync.Mutex // serializes the mining of transactions
+}
+
+// NewSimulatedBackend creates an in-process chain with the given genesis allocation.
+func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	return &SimulatedBackend{SimulatedBackend: backends.NewSimulatedBackend(alloc, gasLimit)}
+}
+
+func (b *SimulatedBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return b.Blockchain().Config().ChainID, nil
+}
+
+func (b *SimulatedBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.Blockchain().CurrentBlock().Number.Uint64(), nil
+}
+
+// HeaderByNumber returns the header of the given block. As the simulated chain mines
+// every transaction right away, the pending and the latest block are the same, so
+// special block numbers are mapped to the latest block here and below.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return b.SimulatedBackend.HeaderByNumber(ctx, latest(number))
+}
+
+func (b *SimulatedBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.SimulatedBackend.NonceAt(ctx, account, latest(blockNumber))
+}
+
+func (b *SimulatedBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return b.SimulatedBackend.BalanceAt(ctx, account, latest(blockNumber))
+}
+
+func (b *SimulatedBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return b.SimulatedBackend.CodeAt(ctx, account, latest(blockNumber))
+}
+
+func (b *SimulatedBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return b.SimulatedBackend.StorageAt(ctx, account, key, latest(blockNumber))
+}
+
+func (b *SimulatedBackend) CallContract(ctx context.Context, msg zond.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return b.SimulatedBackend.CallContract(ctx, msg, latest(blockNumber))
+}
+
+// latest maps the special block numbers like rpc.PendingBlockNumber to nil.
+func latest(number *big.Int) *big.Int {
+	if number != nil && number.Sign() < 0 {
+		return nil
+	}
+	return number
+}
+
+// CreateAccessList creates the access list of the message on the latest state like
+// zond_createAccessList does, by executing it until the access list doesn't change.
+func (b *SimulatedBackend) CreateAccessList(ctx context.Context, msg zond.CallMsg) (*types.AccessList, uint64, string, error) {
+	var (
+		chain  = b.Blockchain()
+		config = chain.Config()
+		header = chain.CurrentHeader()
+	)
+	db, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if msg.Gas == 0 {
+		msg.Gas = header.GasLimit
+	}
+	if msg.Value == nil {
+		msg.Value = new(big.Int)
+	}
+	if msg.GasFeeCap == nil {
+		msg.GasFeeCap = new(big.Int)
+	}
+	if msg.GasTipCap == nil {
+		msg.GasTipCap = new(big.Int)
+	}
+	to := crypto.CreateAddress(msg.From, db.GetNonce(msg.From))
+	if msg.To != nil {
+		to = *msg.To
+	}
+	precompiles := vm.ActivePrecompiles(config.Rules(header.Number, header.Time))
+	prev := logger.NewAccessListTracer(msg.AccessList, msg.From, to, precompiles)
+	for {
+		accessList := prev.AccessList()
+		message := &core.Message{
+			From:              msg.From,
+			To:                msg.To,
+			Value:             msg.Value,
+			GasLimit:          msg.Gas,
+			GasPrice:          math.BigMin(new(big.Int).Add(msg.GasTipCap, header.BaseFee), msg.GasFeeCap),
+			GasFeeCap:         msg.GasFeeCap,
+			GasTipCap:         msg.GasTipCap,
+			Data:              msg.Data,
+			AccessList:        accessList,
+			SkipAccountChecks: true,
+		}
+		tracer := logger.NewAccessListTracer(accessList, msg.From, to, precompiles)
+		zvm := vm.NewZVM(core.NewZVMBlockContext(header, chain, nil), core.NewZVMTxContext(message), db.Copy(), config, vm.Config{Tracer: tracer, NoBaseFee: true})
+		res, err := core.ApplyMessage(zvm, message, new(core.GasPool).AddGas(msg.Gas))
+		if err != nil {
+			return nil, 0, "", err
+		}
+		if tracer.Equal(prev) {
+			var vmErr string
+			if res.Err != nil {
+				vmErr = res.Err.Error()
+			}
+			return &accessList, res.UsedGas, vmErr, nil
+		}
+		prev = tracer
+	}
+}
+
+// SendTransaction executes the transaction and mines it into a new block.
+// Transactions that can not be included into a block are rejected with an error,
+// similar to the transaction pool of a node. Unlike the blocks mined by
+// backends.SimulatedBackend, the block has a prevRandao.
+func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var (
+		chain  = b.Blockchain()
+		config = chain.Config()
+		parent = chain.CurrentHeader()
+	)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		GasLimit:   parent.GasLimit,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       parent.Time + 10,
+		BaseFee:    eip1559.CalcBaseFee(config, parent),
+		// Derived from the parent, so that the chain stays deterministic
+		Random: crypto.Keccak256Hash(parent.Hash().Bytes()),
+	}
+	db, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	db.SetTxContext(tx.Hash(), 0)
+	receipt, err := core.ApplyTransaction(config, chain, &header.Coinbase, new(core.GasPool).AddGas(header.GasLimit), db, header, tx, &header.GasUsed, vm.Config{})
+	if err != nil {
+		return fmt.Errorf("invalid transaction: %w", err)
+	}
+	body := &types.Body{Transactions: []*types.Transaction{tx}}
+	block, err := chain.Engine().FinalizeAndAssemble(chain, header, db, body, []*types.Receipt{receipt})
+	if err != nil {
+		return err
+	}
+	// The simulated chain builds its pending block on the state on disk
+	root, err := db.Commit(header.Number.Uint64(), true)
+	if err != nil {
+		return err
+	}
+	if err := chain.StateCache().TrieDB().Commit(root, false); err != nil {
+		return err
+	}
+	if _, err := chain.InsertChain([]*types.Block{block}); err != nil {
+		return err
+	}
+	// Move the pending block of the simulated chain onto the new head
+	b.Rollback()
+	return nil
+}
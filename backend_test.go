@@ -0,0 +1,103 @@
+package txfuzz
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	zond "github.com/theQRL/go-zond"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/params"
+)
+
+func TestSimulatedCreateAccessList(t *testing.T) {
+	var (
+		sender   = common.Address{1}
+		contract = common.Address{2}
+		other    = common.Address{3}
+	)
+	// PUSH20 other, BALANCE, PUSH1 7, SLOAD
+	code := append([]byte{byte(vm.PUSH20)}, other.Bytes()...)
+	code = append(code, byte(vm.BALANCE), byte(vm.PUSH1), 7, byte(vm.SLOAD))
+	backend := NewSimulatedBackend(core.GenesisAlloc{
+		sender:   {Balance: big.NewInt(params.Ether)},
+		contract: {Code: code},
+	}, params.MaxGasLimit)
+	defer backend.Close()
+
+	al, gasUsed, vmErr, err := backend.CreateAccessList(context.Background(), zond.CallMsg{From: sender, To: &contract})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vmErr != "" {
+		t.Fatalf("execution failed: %v", vmErr)
+	}
+	want := types.AccessList{
+		{Address: contract, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(7))}},
+		{Address: other, StorageKeys: []common.Hash{}},
+	}
+	// The tracer returns the addresses in random order
+	slices.SortFunc(*al, func(a, b types.AccessTuple) int { return a.Address.Cmp(b.Address) })
+	if !reflect.DeepEqual(*al, want) {
+		t.Fatalf("wrong access list: have %v want %v", *al, want)
+	}
+	if gasUsed <= params.TxGas {
+		t.Fatalf("gas used %v does not include the execution", gasUsed)
+	}
+}
+
+func TestSimulatedSendTransaction(t *testing.T) {
+	key, err := dilithium.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := common.Address(key.GetAddress())
+	backend := NewSimulatedBackend(core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}}, params.MaxGasLimit)
+	defer backend.Close()
+
+	send := func(nonce uint64) error {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   params.AllBeaconProtocolChanges.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(params.GWei),
+			Gas:       params.TxGas,
+			To:        &sender,
+		})
+		signedTx, err := types.SignTx(tx, types.NewShanghaiSigner(params.AllBeaconProtocolChanges.ChainID), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return backend.SendTransaction(context.Background(), signedTx)
+	}
+	randoms := make(map[common.Hash]bool)
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := send(nonce); err != nil {
+			t.Fatal(err)
+		}
+		header, err := backend.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Number.Uint64() != nonce+1 {
+			t.Fatalf("transaction not mined: head %v", header.Number)
+		}
+		// Every block has its own prevRandao
+		if header.Random == (common.Hash{}) || randoms[header.Random] {
+			t.Fatalf("block %v: prevRandao %x not unique", header.Number, header.Random)
+		}
+		randoms[header.Random] = true
+	}
+	if pending, err := backend.PendingNonceAt(context.Background(), sender); err != nil || pending != 3 {
+		t.Fatalf("wrong pending nonce: %v %v", pending, err)
+	}
+	if err := send(5); err == nil {
+		t.Fatal("expected a nonce gap to be rejected")
+	}
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/theQRL/go-zond/common/hexutil"
+	"github.com/theQRL/go-zond/core/types"
 	"github.com/theQRL/go-zond/params"
+	"github.com/theQRL/go-zond/rpc"
+	txfuzz "github.com/theQRL/tx-fuzz"
 	"github.com/theQRL/tx-fuzz/flags"
+	"github.com/theQRL/tx-fuzz/mutator"
 	"github.com/theQRL/tx-fuzz/spammer"
 	"github.com/urfave/cli/v2"
 )
@@ -29,6 +38,20 @@ var spamCommand = &cli.Command{
 	Flags:  flags.SpamFlags,
 }
 
+var poolSpamCommand = &cli.Command{
+	Name:   "poolspam",
+	Usage:  "Send transactions stressing the txpool",
+	Action: runPoolSpam,
+	Flags:  flags.SpamFlags,
+}
+
+var feeMarketCommand = &cli.Command{
+	Name:   "feemarket",
+	Usage:  "Send transactions driving the base fee up and down",
+	Action: runFeeMarketSpam,
+	Flags:  append(flags.SpamFlags, flags.ElasticityFlag, flags.BaseFeeDenominatorFlag),
+}
+
 var createCommand = &cli.Command{
 	Name:   "create",
 	Usage:  "Create ephemeral accounts",
@@ -46,6 +69,42 @@ var unstuckCommand = &cli.Command{
 	Flags:  flags.SpamFlags,
 }
 
+var poolCommand = &cli.Command{
+	Name:   "pool",
+	Usage:  "Shows the txpool content of our accounts",
+	Action: runPool,
+	Flags:  append(flags.SpamFlags, flags.JSONFlag),
+}
+
+var importCommand = &cli.Command{
+	Name:   "import",
+	Usage:  "Imports FuzzyVM and gozvmlab artefacts into the corpus",
+	Action: runImport,
+	Flags: []cli.Flag{
+		flags.CorpusFlag,
+		flags.StateTestsFlag,
+		flags.ProgramsFlag,
+		flags.FillersFlag,
+	},
+}
+
+var corpusMinCommand = &cli.Command{
+	Name:   "corpus-min",
+	Usage:  "Minimizes a corpus while preserving the coverage of the generated programs",
+	Action: runCorpusMin,
+	Flags: []cli.Flag{
+		flags.CorpusFlag,
+		flags.OutputFlag,
+	},
+}
+
+var reduceCommand = &cli.Command{
+	Name:   "reduce",
+	Usage:  "Reduces a transaction that crashes a node or makes two nodes diverge",
+	Action: runReduce,
+	Flags:  append(flags.SpamFlags, flags.TxFlag, flags.Rpc2Flag, flags.RestartFlag),
+}
+
 func initApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "tx-fuzz"
@@ -53,8 +112,14 @@ func initApp() *cli.App {
 	app.Commands = []*cli.Command{
 		airdropCommand,
 		spamCommand,
+		poolSpamCommand,
+		feeMarketCommand,
 		createCommand,
 		unstuckCommand,
+		poolCommand,
+		importCommand,
+		corpusMinCommand,
+		reduceCommand,
 	}
 	return app
 }
@@ -88,7 +153,10 @@ func spam(config *spammer.Config, spamFn spammer.Spam, airdropValue *big.Int) er
 			return err
 		}
 		spammer.SpamTransactions(config, spamFn)
-		time.Sleep(12 * time.Second)
+		// The simulated chain mines a block for every transaction
+		if !config.Simulated() {
+			time.Sleep(12 * time.Second)
+		}
 	}
 }
 
@@ -101,6 +169,30 @@ func runBasicSpam(c *cli.Context) error {
 	return spam(config, spammer.SendBasicTransactions, airdropValue)
 }
 
+func runPoolSpam(c *cli.Context) error {
+	config, err := spammer.NewConfigFromContext(c)
+	if err != nil {
+		return err
+	}
+	airdropValue, err := spammer.TxpoolAirdropValue(config)
+	if err != nil {
+		return err
+	}
+	return spam(config, spammer.SendTxpoolTransactions, airdropValue)
+}
+
+func runFeeMarketSpam(c *cli.Context) error {
+	config, err := spammer.NewConfigFromContext(c)
+	if err != nil {
+		return err
+	}
+	airdropValue, err := spammer.FeeMarketAirdropValue(config)
+	if err != nil {
+		return err
+	}
+	return spam(config, spammer.SendFeeMarketTransactions, airdropValue)
+}
+
 func runCreate(c *cli.Context) error {
 	spammer.CreateAddresses(100)
 	return nil
@@ -113,3 +205,143 @@ func runUnstuck(c *cli.Context) error {
 	}
 	return spammer.Unstuck(config)
 }
+
+func runPool(c *cli.Context) error {
+	config, err := spammer.NewConfigFromContext(c)
+	if err != nil {
+		return err
+	}
+	report, err := spammer.InspectPool(config)
+	if err != nil {
+		return err
+	}
+	if c.Bool(flags.JSONFlag.Name) {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	spammer.PrintPoolReport(report)
+	return nil
+}
+
+func runImport(c *cli.Context) error {
+	corpusDir := c.String(flags.CorpusFlag.Name)
+	if corpusDir == "" {
+		return errors.New("no corpus directory specified")
+	}
+	corpus, err := spammer.NewCorpus(corpusDir)
+	if err != nil {
+		return err
+	}
+	importers := []struct {
+		flag   *cli.StringFlag
+		fn     func(string) ([][]byte, error)
+		add    func([]byte) error
+		target string
+	}{
+		{flags.StateTestsFlag, spammer.ImportStateTests, corpus.AddCode, "bytecode"},
+		{flags.ProgramsFlag, spammer.ImportPrograms, corpus.AddCode, "bytecode"},
+		{flags.FillersFlag, spammer.ImportFillers, corpus.Add, "filler"},
+	}
+	for _, importer := range importers {
+		path := c.String(importer.flag.Name)
+		if path == "" {
+			continue
+		}
+		elems, err := importer.fn(path)
+		if err != nil {
+			return err
+		}
+		for _, elem := range elems {
+			if err := importer.add(elem); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Imported %v %v elements from %v\n", len(elems), importer.target, path)
+	}
+	return nil
+}
+
+func runCorpusMin(c *cli.Context) error {
+	corpusDir, outDir := c.String(flags.CorpusFlag.Name), c.String(flags.OutputFlag.Name)
+	if corpusDir == "" || outDir == "" {
+		return errors.New("corpus and output directory need to be specified")
+	}
+	in, err := spammer.NewCorpus(corpusDir)
+	if err != nil {
+		return err
+	}
+	out, err := spammer.NewCorpus(outDir)
+	if err != nil {
+		return err
+	}
+	elems := in.Elements()
+	minimized := spammer.MinimizeCorpus(elems)
+	mut := mutator.NewMutator(rand.New(rand.NewSource(time.Now().UnixNano())))
+	var before, after int
+	for _, elem := range elems {
+		before += len(elem)
+	}
+	for _, elem := range minimized {
+		small := spammer.MinimizeElement(mut, elem)
+		after += len(small)
+		if err := out.Add(small); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Minimized corpus from %v to %v elements, %v to %v bytes\n", len(elems), len(minimized), before, after)
+	return nil
+}
+
+func runReduce(c *cli.Context) error {
+	config, err := spammer.NewConfigFromContext(c)
+	if err != nil {
+		return err
+	}
+	input, err := os.ReadFile(c.String(flags.TxFlag.Name))
+	if err != nil {
+		return err
+	}
+	raw, err := hexutil.Decode(strings.TrimSpace(string(input)))
+	if err != nil {
+		return err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return err
+	}
+	var (
+		oracle  txfuzz.Oracle
+		restart = c.String(flags.RestartFlag.Name)
+	)
+	if rpc2 := c.String(flags.Rpc2Flag.Name); rpc2 != "" {
+		other, err := rpc.Dial(rpc2)
+		if err != nil {
+			return err
+		}
+		oracle = spammer.DivergenceOracle(config, txfuzz.NewRPCBackend(other), restart)
+	} else {
+		// A crashed node needs to be restarted before the next attempt
+		if restart == "" {
+			return errors.New("reducing a crash needs a restart command")
+		}
+		oracle = spammer.CrashOracle(config, restart)
+	}
+	reduced, err := spammer.ReduceTransaction(tx, oracle)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(types.NewTx(reduced), "", "  ")
+	if err != nil {
+		return err
+	}
+	enc, err := types.NewTx(reduced).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Reduced transaction:\n%v\n%v\n", string(out), hexutil.Encode(enc))
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/params"
+	"github.com/theQRL/go-zond/rpc"
+	txfuzz "github.com/theQRL/tx-fuzz"
+	"github.com/theQRL/tx-fuzz/flags"
+	"github.com/theQRL/tx-fuzz/scenario"
+	"github.com/urfave/cli/v2"
+)
+
+var listCommand = &cli.Command{
+	Name:   "list",
+	Usage:  "Lists the available scenarios",
+	Action: runList,
+}
+
+var runCommand = &cli.Command{
+	Name:      "run",
+	Usage:     "Runs the named scenarios, or all of them, and reports which steps failed",
+	ArgsUsage: "[<name>...]",
+	Action:    runScenarios,
+	Flags: []cli.Flag{
+		flags.SeedFlag,
+		flags.RpcFlag,
+		flags.SimulatedFlag,
+	},
+}
+
+func initApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "scenario"
+	app.Usage = "Deterministic hard fork tests with expected outcomes"
+	app.Commands = []*cli.Command{
+		listCommand,
+		runCommand,
+	}
+	return app
+}
+
+var app = initApp()
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runList(c *cli.Context) error {
+	for _, s := range scenario.All() {
+		fmt.Printf("%-10v %v\n", s.Name, s.Description)
+	}
+	return nil
+}
+
+func runScenarios(c *cli.Context) error {
+	scenarios := scenario.All()
+	if c.NArg() != 0 {
+		scenarios = nil
+		for _, name := range c.Args().Slice() {
+			s := scenario.Get(name)
+			if s == nil {
+				return fmt.Errorf("unknown scenario %v", name)
+			}
+			scenarios = append(scenarios, s)
+		}
+	}
+	var (
+		key     *dilithium.Dilithium
+		backend txfuzz.Backend
+		err     error
+	)
+	if c.Bool(flags.SimulatedFlag.Name) {
+		// Fund a fresh account on the simulated chain
+		if key, err = dilithium.New(); err != nil {
+			return err
+		}
+		alloc := core.GenesisAlloc{
+			key.GetAddress(): {Balance: new(big.Int).Lsh(big.NewInt(1), 128)},
+		}
+		backend = txfuzz.NewSimulatedBackend(alloc, params.MaxGasLimit)
+	} else {
+		if key, err = dilithium.NewDilithiumFromHexSeed(c.String(flags.SeedFlag.Name)[2:]); err != nil {
+			return err
+		}
+		client, err := rpc.Dial(c.String(flags.RpcFlag.Name))
+		if err != nil {
+			return err
+		}
+		backend = txfuzz.NewRPCBackend(client)
+	}
+	var passed, failed int
+	for _, s := range scenarios {
+		fmt.Printf("Running %v\n", s.Name)
+		results, err := scenario.Run(context.Background(), backend, key, s)
+		for _, res := range results {
+			fmt.Printf("  %v\n", res)
+			if res.Err != nil {
+				failed++
+			} else {
+				passed++
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%v passed, %v failed\n", passed, failed)
+	if failed != 0 {
+		return errors.New("scenarios failed")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package txfuzz
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+)
+
+// maxTrackedContracts bounds the number of contracts a ContractTracker remembers.
+const maxTrackedContracts = 1024
+
+// ContractTracker keeps track of contracts deployed by fuzz transactions, so that
+// subsequent transactions can call into real on-chain code.
+type ContractTracker struct {
+	mu        sync.RWMutex
+	contracts []common.Address
+	known     map[common.Address]struct{}
+}
+
+// NewContractTracker creates a new, empty contract tracker.
+func NewContractTracker() *ContractTracker {
+	return &ContractTracker{
+		known: make(map[common.Address]struct{}),
+	}
+}
+
+// Observe adds the contract created by a successful contract creation to the tracker.
+func (t *ContractTracker) Observe(receipt *types.Receipt) {
+	if receipt.Status != types.ReceiptStatusSuccessful || receipt.ContractAddress == (common.Address{}) {
+		return
+	}
+	t.Add(receipt.ContractAddress)
+}
+
+// Add adds a contract address to the tracker. If the tracker is full,
+// a random tracked contract is replaced.
+func (t *ContractTracker) Add(addr common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.known[addr]; ok {
+		return
+	}
+	t.known[addr] = struct{}{}
+	if len(t.contracts) < maxTrackedContracts {
+		t.contracts = append(t.contracts, addr)
+		return
+	}
+	index := rand.Intn(len(t.contracts))
+	delete(t.known, t.contracts[index])
+	t.contracts[index] = addr
+}
+
+// Random returns a random tracked contract or false if no contracts are tracked.
+func (t *ContractTracker) Random() (common.Address, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.contracts) == 0 {
+		return common.Address{}, false
+	}
+	return t.contracts[rand.Intn(len(t.contracts))], true
+}
+
+// Len returns the number of tracked contracts.
+func (t *ContractTracker) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.contracts)
+}
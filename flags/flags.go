@@ -1,6 +1,9 @@
 package flags
 
-import "github.com/urfave/cli/v2"
+import (
+	"github.com/theQRL/go-zond/params"
+	"github.com/urfave/cli/v2"
+)
 
 var (
 	/*
@@ -19,7 +22,7 @@ var (
 
 	CorpusFlag = &cli.StringFlag{
 		Name:  "corpus",
-		Usage: "Use additional Corpus",
+		Usage: "Corpus directory to use elements from, interesting inputs are written back to it",
 	}
 
 	NoALFlag = &cli.BoolFlag{
@@ -52,6 +55,94 @@ var (
 		Value: 100_000,
 	}
 
+	EstimateGasFlag = &cli.Float64Flag{
+		Name:  "estimate-gas",
+		Usage: "Estimate the gas of transactions and scale it by the given multiplier of at least 1, 0 = disabled",
+		Value: 0,
+	}
+
+	CoverageFlag = &cli.BoolFlag{
+		Name:  "coverage",
+		Usage: "Trace included transactions via debug_traceTransaction and prioritise corpus elements that increase coverage",
+		Value: false,
+	}
+
+	AccessListOracleFlag = &cli.BoolFlag{
+		Name:  "check-al",
+		Usage: "Trace transactions with access lists via debug_traceTransaction and verify their gas accounting",
+		Value: false,
+	}
+
+	DictionaryFlag = &cli.StringFlag{
+		Name:  "dict",
+		Usage: "Dictionary file in AFL/libFuzzer format with byte sequences the mutators insert",
+	}
+
+	AdaptiveFlag = &cli.BoolFlag{
+		Name:  "adaptive",
+		Usage: "Pick mutators by how often they produced new outcomes or coverage, needs --corpus",
+		Value: false,
+	}
+
+	SimulatedFlag = &cli.BoolFlag{
+		Name:  "sim",
+		Usage: "Run against an in-process simulated chain instead of the RPC provider",
+		Value: false,
+	}
+
+	ElasticityFlag = &cli.Uint64Flag{
+		Name:  "elasticity",
+		Usage: "EIP-1559 elasticity multiplier of the target chain, used to predict the base fee",
+		Value: params.DefaultElasticityMultiplier,
+	}
+
+	BaseFeeDenominatorFlag = &cli.Uint64Flag{
+		Name:  "basefee-denominator",
+		Usage: "EIP-1559 base fee change denominator of the target chain, used to predict the base fee",
+		Value: params.DefaultBaseFeeChangeDenominator,
+	}
+
+	StateTestsFlag = &cli.StringFlag{
+		Name:  "statetests",
+		Usage: "File or directory of FuzzyVM or gozvmlab state tests to import bytecode from",
+	}
+
+	ProgramsFlag = &cli.StringFlag{
+		Name:  "programs",
+		Usage: "File or directory of raw or hex encoded gozvmlab programs to import",
+	}
+
+	FillersFlag = &cli.StringFlag{
+		Name:  "fillers",
+		Usage: "File or directory of raw filler inputs, e.g. the FuzzyVM corpus, to import",
+	}
+
+	OutputFlag = &cli.StringFlag{
+		Name:  "out",
+		Usage: "Output directory",
+	}
+
+	TxFlag = &cli.StringFlag{
+		Name:  "tx",
+		Usage: "File containing a hex encoded transaction",
+	}
+
+	Rpc2Flag = &cli.StringFlag{
+		Name:  "rpc2",
+		Usage: "Second RPC provider to compare the results against",
+	}
+
+	RestartFlag = &cli.StringFlag{
+		Name:  "restart",
+		Usage: "Shell command to restart the node(s) with a fresh state",
+	}
+
+	JSONFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output results as JSON",
+		Value: false,
+	}
+
 	SpamFlags = []cli.Flag{
 		SeedFlag,
 		NoALFlag,
@@ -60,5 +151,11 @@ var (
 		TxCountFlag,
 		CountFlag,
 		GasLimitFlag,
+		EstimateGasFlag,
+		CoverageFlag,
+		AccessListOracleFlag,
+		SimulatedFlag,
+		DictionaryFlag,
+		AdaptiveFlag,
 	}
 )
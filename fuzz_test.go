@@ -0,0 +1,135 @@
+package txfuzz
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/rlp"
+)
+
+// FuzzRandomValidTx creates transactions from the filler input and checks
+// that they survive an encoding round trip.
+func FuzzRandomValidTx(f *testing.F) {
+	f.Add([]byte{}, false)
+	f.Add(bytes.Repeat([]byte{0xff}, 256), true)
+	f.Add([]byte("tx-fuzz"), true)
+	f.Fuzz(func(t *testing.T, data []byte, al bool) {
+		fill := filler.NewFiller(data)
+		tx, err := RandomValidTx(nil, fill, common.Address{}, 0, big.NewInt(1), big.NewInt(1), big.NewInt(1), al, nil)
+		if err != nil {
+			return
+		}
+		checkRoundTrip(t, tx)
+	})
+}
+
+// FuzzMutateAccessList mutates access lists built from the input and checks
+// that the mutated lists stay encodable, bounded and reproducible.
+func FuzzMutateAccessList(f *testing.F) {
+	f.Add([]byte{}, int64(0))
+	f.Add([]byte{0}, int64(1))
+	f.Add([]byte{1, 0}, int64(2))
+	f.Add(bytes.Repeat([]byte{3}, 128), int64(3))
+	f.Fuzz(func(t *testing.T, data []byte, seed int64) {
+		var (
+			list     = accessListFromBytes(data)
+			involved = []common.Address{{1}, {2}, {3}}
+			m1       = NewAccessListMutator(rand.New(rand.NewSource(seed)))
+			m2       = NewAccessListMutator(rand.New(rand.NewSource(seed)))
+		)
+		for i := 0; i < 16; i++ {
+			mutated := m1.Mutate(list, involved...)
+			enc, err := rlp.EncodeToBytes(mutated)
+			if err != nil {
+				t.Fatalf("failed to encode mutated list: %v", err)
+			}
+			if len(enc) > max(maxDataPerTx, len(data)*common.HashLength*2) {
+				t.Fatalf("mutated list too large: %d bytes", len(enc))
+			}
+			var dec types.AccessList
+			if err := rlp.DecodeBytes(enc, &dec); err != nil {
+				t.Fatalf("failed to decode mutated list: %v", err)
+			}
+			if len(dec) != len(mutated) || dec.StorageKeys() != mutated.StorageKeys() {
+				t.Fatalf("mutated list changed by round trip")
+			}
+			again, err := rlp.EncodeToBytes(m2.Mutate(list, involved...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(enc, again) {
+				t.Fatalf("mutation not reproducible with the same seed")
+			}
+			list = mutated
+		}
+	})
+}
+
+// FuzzTxRoundTrip checks that decodable transactions encode to the same bytes.
+func FuzzTxRoundTrip(f *testing.F) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:    big.NewInt(1),
+		Nonce:      1,
+		GasTipCap:  big.NewInt(1),
+		GasFeeCap:  big.NewInt(2),
+		Gas:        21000,
+		To:         &common.Address{1},
+		Value:      big.NewInt(3),
+		Data:       []byte{0x5b},
+		AccessList: types.AccessList{{Address: common.Address{2}, StorageKeys: []common.Hash{{3}}}},
+	})
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(enc)
+	f.Add([]byte{types.DynamicFeeTxType})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return
+		}
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to encode decoded tx: %v", err)
+		}
+		if !bytes.Equal(enc, data) {
+			t.Fatalf("encoding mismatch:\nhave %x\nwant %x", enc, data)
+		}
+	})
+}
+
+func checkRoundTrip(t *testing.T, tx *types.Transaction) {
+	t.Helper()
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	dec := new(types.Transaction)
+	if err := dec.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("failed to decode tx: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Fatalf("hash mismatch after round trip: have %v want %v", dec.Hash(), tx.Hash())
+	}
+}
+
+// accessListFromBytes builds an access list where every byte of data adds
+// either an address or a storage key to the last address.
+func accessListFromBytes(data []byte) types.AccessList {
+	var list types.AccessList
+	for i, b := range data {
+		if b%4 == 0 || len(list) == 0 {
+			list = append(list, types.AccessTuple{Address: common.Address{b, byte(i)}, StorageKeys: []common.Hash{}})
+			continue
+		}
+		last := &list[len(list)-1]
+		last.StorageKeys = append(last.StorageKeys, common.Hash{b, byte(i)})
+	}
+	return list
+}
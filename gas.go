@@ -0,0 +1,207 @@
+package txfuzz
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	zond "github.com/theQRL/go-zond"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+// defaultGasLimit is used if no gas limit is configured.
+const defaultGasLimit = 100000
+
+// gasStrategy picks a gas limit for a transaction sending data to the given address
+// (or creating a contract if to is nil) with the given access list.
+type gasStrategy func(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64
+
+// gasStrategies are weighted by repetition, the configured gas limit is used most often
+// so that the majority of the transactions is still accepted by the pool.
+var gasStrategies = []gasStrategy{
+	configuredGas,
+	configuredGas,
+	configuredGas,
+	configuredGas,
+	intrinsicGasBoundary,
+	estimatedGasBoundary,
+	blockGasLimitBoundary,
+	mutatedGas,
+}
+
+// randomGasLimit picks a gas limit around a meaningful boundary.
+// If a gas estimator is configured, the scaled estimation is used instead and
+// the boundaries are only used if the estimation fails.
+func randomGasLimit(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	if conf.estimator != nil {
+		if gas, ok := conf.estimator.estimate(conf, to, data, al); ok {
+			return gas
+		}
+	}
+	index := rand.Intn(len(gasStrategies))
+	return gasStrategies[index](conf, to, data, al)
+}
+
+// configuredGas uses the gas limit of the configuration.
+func configuredGas(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	return conf.gasLimit
+}
+
+// intrinsicGasBoundary uses the intrinsic gas of the transaction -1, +0 or +1.
+func intrinsicGasBoundary(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	gas, err := core.IntrinsicGas(data, al, to == nil)
+	if err != nil {
+		return conf.gasLimit
+	}
+	return offByOne(gas)
+}
+
+// estimatedGasBoundary uses the estimation of the node -1, +0 or +1.
+func estimatedGasBoundary(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	gas, err := estimateGas(conf, to, data, al)
+	if err != nil {
+		return conf.gasLimit
+	}
+	return offByOne(gas)
+}
+
+// blockGasLimitBoundary uses the gas limit of the latest block or one more.
+func blockGasLimitBoundary(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	if conf.backend == nil {
+		return conf.gasLimit
+	}
+	header, err := conf.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return conf.gasLimit
+	}
+	return header.GasLimit + uint64(rand.Intn(2))
+}
+
+// mutatedGas mutates the configured gas limit, e.g. to an interesting value or
+// with a bit flipped.
+func mutatedGas(conf *txConf, to *common.Address, data []byte, al types.AccessList) uint64 {
+	m := mutator.NewMutator(rand.New(rand.NewSource(rand.Int63())))
+	return m.MutateUint64(conf.gasLimit)
+}
+
+// estimateGas estimates the gas of the transaction via zond_estimateGas.
+func estimateGas(conf *txConf, to *common.Address, data []byte, al types.AccessList) (uint64, error) {
+	if conf.backend == nil {
+		return core.IntrinsicGas(data, al, to == nil)
+	}
+	msg := zond.CallMsg{
+		From:       conf.sender,
+		To:         to,
+		GasFeeCap:  conf.gasFeeCap,
+		GasTipCap:  conf.gasTipCap,
+		Value:      conf.value,
+		Data:       data,
+		AccessList: al,
+	}
+	return conf.backend.EstimateGas(context.Background(), msg)
+}
+
+func offByOne(gas uint64) uint64 {
+	switch rand.Intn(3) {
+	case 0:
+		if gas > 0 {
+			return gas - 1
+		}
+	case 1:
+		return gas + 1
+	}
+	return gas
+}
+
+// GasEstimator estimates the gas of generated transactions via zond_estimateGas and
+// scales the estimation by Multiplier. It keeps statistics about how often the
+// estimation disagreed with the gas actually used.
+type GasEstimator struct {
+	Multiplier float64
+
+	mu        sync.Mutex
+	estimates map[estimateKey]uint64
+	stats     GasEstimatorStats
+}
+
+type estimateKey struct {
+	sender common.Address
+	nonce  uint64
+}
+
+// GasEstimatorStats are the statistics of a GasEstimator.
+type GasEstimatorStats struct {
+	Estimated uint64 // transactions with an estimated gas limit
+	Failed    uint64 // estimations that failed and fell back to random gas
+	Observed  uint64 // receipts compared against their estimation
+	Disagreed uint64 // receipts that used more than the estimation or ran out of gas with it
+	OutOfGas  uint64 // receipts that used all of the scaled estimation
+}
+
+func (s GasEstimatorStats) String() string {
+	return fmt.Sprintf("gas estimation: estimated: %v failed: %v observed: %v disagreed: %v out of gas: %v", s.Estimated, s.Failed, s.Observed, s.Disagreed, s.OutOfGas)
+}
+
+// NewGasEstimator creates a new gas estimator scaling estimations by the multiplier.
+// Multipliers below 1 make transactions run out of gas and should be rejected by callers.
+func NewGasEstimator(multiplier float64) *GasEstimator {
+	return &GasEstimator{
+		Multiplier: multiplier,
+		estimates:  make(map[estimateKey]uint64),
+	}
+}
+
+// estimate returns the scaled gas estimation or false if the estimation failed.
+func (e *GasEstimator) estimate(conf *txConf, to *common.Address, data []byte, al types.AccessList) (uint64, bool) {
+	gas, err := estimateGas(conf, to, data, al)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		e.stats.Failed++
+		return 0, false
+	}
+	e.stats.Estimated++
+	e.estimates[estimateKey{conf.sender, conf.nonce}] = gas
+	return uint64(float64(gas) * e.Multiplier), true
+}
+
+// Observe compares the gas used by an included transaction of sender against its estimation.
+func (e *GasEstimator) Observe(sender common.Address, tx *types.Transaction, receipt *types.Receipt) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := estimateKey{sender, tx.Nonce()}
+	estimate, ok := e.estimates[key]
+	if !ok {
+		return
+	}
+	delete(e.estimates, key)
+	e.stats.Observed++
+	// Refunds and unused stipends make gasUsed fall short of the estimation, which is
+	// fine. The estimation was too low if it did not cover the execution.
+	outOfGas := receipt.Status == types.ReceiptStatusFailed && receipt.GasUsed >= tx.Gas()
+	if receipt.GasUsed > estimate || (outOfGas && tx.Gas() >= estimate) {
+		e.stats.Disagreed++
+	}
+	if receipt.GasUsed >= tx.Gas() {
+		e.stats.OutOfGas++
+	}
+}
+
+// Discard drops the estimation of a transaction of sender that was not sent or
+// whose receipt is not available.
+func (e *GasEstimator) Discard(sender common.Address, tx *types.Transaction) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.estimates, estimateKey{sender, tx.Nonce()})
+}
+
+// Stats returns a copy of the current statistics.
+func (e *GasEstimator) Stats() GasEstimatorStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
@@ -0,0 +1,45 @@
+package txfuzz
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+)
+
+func TestGasEstimatorObserve(t *testing.T) {
+	sender := common.Address{1}
+	tests := []struct {
+		gas       uint64
+		status    uint64
+		gasUsed   uint64
+		disagreed bool
+	}{
+		// Refunds lower gasUsed below the estimation
+		{60_000, types.ReceiptStatusSuccessful, 50_000, false},
+		{60_000, types.ReceiptStatusSuccessful, 40_000, false},
+		{60_000, types.ReceiptStatusSuccessful, 50_001, true},
+		// Running out of gas below the estimation is expected
+		{49_999, types.ReceiptStatusFailed, 49_999, false},
+		{50_000, types.ReceiptStatusFailed, 50_000, true},
+		{60_000, types.ReceiptStatusFailed, 60_000, true},
+		// Reverts don't use all of the gas
+		{60_000, types.ReceiptStatusFailed, 30_000, false},
+	}
+	for i, test := range tests {
+		e := NewGasEstimator(1)
+		e.estimates[estimateKey{sender, 1}] = 50_000
+		tx := types.NewTx(&types.DynamicFeeTx{Nonce: 1, Gas: test.gas, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)})
+		e.Observe(sender, tx, &types.Receipt{Status: test.status, GasUsed: test.gasUsed})
+		// Transactions are only observed once
+		e.Observe(sender, tx, &types.Receipt{Status: test.status, GasUsed: test.gasUsed})
+		stats := e.Stats()
+		if stats.Observed != 1 {
+			t.Errorf("test %d: observed %d times", i, stats.Observed)
+		}
+		if disagreed := stats.Disagreed == 1; disagreed != test.disagreed {
+			t.Errorf("test %d: disagreed %v want %v", i, disagreed, test.disagreed)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package mutator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseDictionary parses a dictionary in the format used by AFL and libFuzzer.
+// Every line holds one quoted entry, optionally preceded by a name, e.g.
+//
+//	# EOF magic
+//	eof="\xef\x00"
+//	"\x60\x00\x60\x00\xf3"
+//
+// Empty lines and lines starting with # are ignored.
+func ParseDictionary(r io.Reader) ([][]byte, error) {
+	var (
+		dict    [][]byte
+		scanner = bufio.NewScanner(r)
+	)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.IndexByte(text, '"'); i > 0 {
+			// Drop the name, AFL allows a level like name@1 that we ignore
+			if !strings.HasSuffix(strings.TrimSpace(text[:i]), "=") {
+				return nil, fmt.Errorf("line %d: invalid entry %q", line, text)
+			}
+			text = text[i:]
+		}
+		entry, err := unquoteEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(entry) != 0 {
+			dict = append(dict, entry)
+		}
+	}
+	return dict, scanner.Err()
+}
+
+// LoadDictionary reads a dictionary from a file, see ParseDictionary.
+func LoadDictionary(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDictionary(f)
+}
+
+// unquoteEntry decodes a quoted entry with \xNN, \\ and \" escapes.
+func unquoteEntry(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return nil, fmt.Errorf("entry %q is not quoted", s)
+	}
+	s = s[1 : len(s)-1]
+	var entry []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			entry = append(entry, s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return nil, fmt.Errorf("entry %q ends with an escape", s)
+		}
+		switch s[i+1] {
+		case '\\', '"':
+			entry = append(entry, s[i+1])
+			i++
+		case 'x':
+			if i+4 > len(s) {
+				return nil, fmt.Errorf("entry %q has a truncated hex escape", s)
+			}
+			b, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("entry %q has an invalid hex escape", s)
+			}
+			entry = append(entry, byte(b))
+			i += 3
+		default:
+			return nil, fmt.Errorf("entry %q has an invalid escape", s)
+		}
+	}
+	return entry, nil
+}
+
+// SetDictionary sets the entries the constant inserting and overwriting mutators
+// draw from. The bytecode mutator inserts entries as instruction sequences and
+// uses them as PUSH values.
+func (m *Mutator) SetDictionary(dict [][]byte) {
+	m.dict = dict
+}
+
+// dictEntry returns a random entry of the dictionary or nil if it is empty.
+func (m *Mutator) dictEntry() []byte {
+	if len(m.dict) == 0 {
+		return nil
+	}
+	return m.dict[m.rand(len(m.dict))]
+}
+
+// Splice returns a new input that starts with a prefix of a and continues with a
+// suffix of b, like the splicing stage of AFL. The inputs are not modified.
+func (m *Mutator) Splice(a, b []byte) []byte {
+	// Split somewhere within the range in which the inputs differ
+	first, last := 0, min(len(a), len(b))-1
+	for first < min(len(a), len(b)) && a[first] == b[first] {
+		first++
+	}
+	for last > first && a[last] == b[last] {
+		last--
+	}
+	split := first
+	if last > first {
+		split += m.rand(last - first)
+	}
+	res := make([]byte, 0, len(b))
+	res = append(res, a[:split]...)
+	return append(res, b[split:]...)
+}
@@ -0,0 +1,50 @@
+package mutator
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseDictionary(t *testing.T) {
+	input := `
+# comment
+magic="\xef\x00"
+"tx-fuzz"
+quoted@1="\"\\"
+`
+	dict, err := ParseDictionary(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{{0xef, 0x00}, []byte("tx-fuzz"), []byte(`"\`)}
+	if len(dict) != len(want) {
+		t.Fatalf("wrong number of entries: have %d want %d", len(dict), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(dict[i], want[i]) {
+			t.Errorf("entry %d: have %x want %x", i, dict[i], want[i])
+		}
+	}
+	for _, invalid := range []string{`unquoted`, `name "x"`, `"\x0"`, `"\xzz"`, `"\n"`, `"x\"`} {
+		if _, err := ParseDictionary(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+	if _, err := LoadDictionary("zvm.dict"); err != nil {
+		t.Fatalf("example dictionary: %v", err)
+	}
+}
+
+func TestSplice(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	a, b := []byte("aaaaaaaa"), []byte("bbbbbbbbbbbb")
+	for i := 0; i < 100; i++ {
+		res := m.Splice(a, b)
+		split := bytes.IndexByte(res, 'b')
+		if len(res) != len(b) || !bytes.Equal(res[:split], a[:split]) || !bytes.Equal(res[split:], b[split:]) {
+			t.Fatalf("invalid splice %s", res)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package mutator
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/theQRL/go-zond/core/vm"
+)
+
+// FuzzMutateBytes mutates the input repeatedly and checks that the mutations
+// leave the input untouched and stay within the maximum length.
+func FuzzMutateBytes(f *testing.F) {
+	f.Add([]byte{}, int64(0))
+	f.Add([]byte{0}, int64(0))
+	f.Add([]byte("tx-fuzz"), int64(1))
+	f.Add(make([]byte, 1024), int64(2))
+	f.Fuzz(func(t *testing.T, data []byte, seed int64) {
+		m := NewMutator(rand.New(rand.NewSource(seed)))
+		m.SetDictionary([][]byte{{0xef}, data})
+		maxLen := 2 * len(data)
+		m.SetMaxLen(maxLen)
+		input := bytes.Clone(data)
+		b := data
+		for i := 0; i < 16; i++ {
+			var applied []string
+			b, applied = m.MutateBytes(b)
+			if len(b) > max(maxLen, len(data)) {
+				t.Fatalf("mutated slice exceeds the maximum length: %d > %d", len(b), maxLen)
+			}
+			if len(applied) > maxStackedMutations {
+				t.Fatalf("too many stacked mutations: %v", applied)
+			}
+		}
+		if !bytes.Equal(data, input) {
+			t.Fatalf("input modified")
+		}
+	})
+}
+
+// FuzzMutateCode checks that code mutations keep instructions aligned, never
+// remove JUMPDESTs and keep static jumps pointing to JUMPDESTs.
+func FuzzMutateCode(f *testing.F) {
+	f.Add([]byte{0x60, 0x01, 0x60, 0x02, 0x01}, []byte{}, int64(0))
+	f.Add([]byte{0x7f, 0x00}, []byte{0x5b, 0x00}, int64(1))
+	// PUSH1 4 JUMP INVALID JUMPDEST PUSH1 0 PUSH1 4 JUMPI STOP
+	f.Add([]byte{0x60, 0x04, 0x56, 0xfe, 0x5b, 0x60, 0x00, 0x60, 0x04, 0x57, 0x00}, []byte{0x60, 0x00, 0x55}, int64(2))
+	f.Fuzz(func(t *testing.T, code, other []byte, seed int64) {
+		m := NewMutator(rand.New(rand.NewSource(seed)))
+		m.SetDictionary([][]byte{other})
+		before := parseCode(code)
+		mutated := m.MutateCode(code, other)
+		after := parseCode(mutated)
+		if reassembled := assembleCode(after); !bytes.Equal(reassembled, mutated) {
+			t.Fatalf("instructions not aligned: %x -> %x", mutated, reassembled)
+		}
+		if have, want := countInstructions(after, isJumpdest), countInstructions(before, isJumpdest); have < want {
+			t.Fatalf("jumpdests removed: have %d want %d: %x -> %x", have, want, code, mutated)
+		}
+		if have, want := countInstructions(after, isStaticJump), countInstructions(before, isStaticJump); have < want {
+			t.Fatalf("static jumps broken: have %d want %d: %x -> %x", have, want, code, mutated)
+		}
+	})
+}
+
+func isJumpdest(in *instruction) bool   { return in.op == vm.JUMPDEST }
+func isStaticJump(in *instruction) bool { return in.target != nil }
+
+func countInstructions(ins []*instruction, filter func(*instruction) bool) int {
+	var n int
+	for _, in := range ins {
+		if filter(in) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTruncateCode(t *testing.T) {
+	// PUSH2 0x0102 ADD PUSH1
+	code := []byte{0x61, 0x01, 0x02, 0x01, 0x60}
+	for size, want := range [][]byte{{}, {}, {}, code[:3], code[:4], code[:4], code} {
+		if have := TruncateCode(code, size); !bytes.Equal(have, want) {
+			t.Errorf("size %d: have %x want %x", size, have, want)
+		}
+	}
+}
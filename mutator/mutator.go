@@ -6,8 +6,14 @@ package mutator
 import (
 	"encoding/binary"
 	"math/rand"
-	"reflect"
-	"unsafe"
+	"sync"
+)
+
+const (
+	// defaultMaxLen is the default maximum length mutated inputs can grow to.
+	defaultMaxLen = 32 * 1024
+	// maxStackedMutations is the maximum number of mutations applied at once.
+	maxStackedMutations = 8
 )
 
 var (
@@ -26,11 +32,26 @@ func init() {
 }
 
 type Mutator struct {
-	r *rand.Rand
+	r      *rand.Rand
+	dict   [][]byte // optional dictionary of interesting byte sequences
+	maxLen int      // maximum length of mutated inputs
+
+	mu       sync.Mutex
+	stats    MutationStats // statistics of the byte slice mutators
+	adaptive bool          // whether to pick mutators by their success rate
 }
 
 func NewMutator(r *rand.Rand) *Mutator {
-	return &Mutator{r: r}
+	stats := make(MutationStats, len(byteSliceMutators))
+	for i, mut := range byteSliceMutators {
+		stats[i].Name = mut.name
+	}
+	return &Mutator{r: r, maxLen: defaultMaxLen, stats: stats}
+}
+
+// SetMaxLen sets the maximum length mutated inputs can grow to.
+func (m *Mutator) SetMaxLen(n int) {
+	m.maxLen = n
 }
 
 func (m *Mutator) rand(n int) int {
@@ -68,6 +89,22 @@ func (m *Mutator) FillBytes(ptr *[]byte) {
 	m.r.Read(*ptr)
 }
 
+// Intn returns a random number in [0, n) from the source of the mutator, so that
+// decisions taken alongside the mutations are reproducible from the seed.
+func (m *Mutator) Intn(n int) int {
+	return m.rand(n)
+}
+
+// Fork returns a mutator with the dictionary and maximum length of m whose source is
+// seeded from m. Mutators are not safe for concurrent use, so every goroutine needs
+// its own fork to keep the mutations reproducible from the seed.
+func (m *Mutator) Fork() *Mutator {
+	fork := NewMutator(rand.New(rand.NewSource(m.r.Int63())))
+	fork.dict = m.dict
+	fork.maxLen = m.maxLen
+	return fork
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -77,43 +114,61 @@ func min(a, b int) int {
 
 type byteSliceMutator func(*Mutator, []byte) []byte
 
-var byteSliceMutators = []byteSliceMutator{
-	byteSliceRemoveBytes,
-	byteSliceInsertRandomBytes,
-	byteSliceDuplicateBytes,
-	byteSliceOverwriteBytes,
-	byteSliceBitFlip,
-	byteSliceXORByte,
-	byteSliceSwapByte,
-	byteSliceArithmeticUint8,
-	byteSliceArithmeticUint16,
-	byteSliceArithmeticUint32,
-	byteSliceArithmeticUint64,
-	byteSliceOverwriteInterestingUint8,
-	byteSliceOverwriteInterestingUint16,
-	byteSliceOverwriteInterestingUint32,
-	byteSliceInsertConstantBytes,
-	byteSliceOverwriteConstantBytes,
-	byteSliceShuffleBytes,
-	byteSliceSwapBytes,
-}
-
-func (m *Mutator) MutateBytes(ptrB *[]byte) {
-	b := *ptrB
-	defer func() {
-		oldHdr := (*reflect.SliceHeader)(unsafe.Pointer(ptrB))
-		newHdr := (*reflect.SliceHeader)(unsafe.Pointer(&b))
-		if oldHdr.Data != newHdr.Data {
-			panic("data moved to new address")
-		}
-		*ptrB = b
-	}()
-
-	for {
-		mut := byteSliceMutators[m.rand(len(byteSliceMutators))]
-		if mutated := mut(m, b); mutated != nil {
-			b = mutated
-			return
+// namedByteSliceMutator is a byte slice mutator together with the name that
+// MutateBytes reports.
+type namedByteSliceMutator struct {
+	name   string
+	mutate byteSliceMutator
+}
+
+var byteSliceMutators = []namedByteSliceMutator{
+	{"RemoveBytes", byteSliceRemoveBytes},
+	{"InsertRandomBytes", byteSliceInsertRandomBytes},
+	{"DuplicateBytes", byteSliceDuplicateBytes},
+	{"OverwriteBytes", byteSliceOverwriteBytes},
+	{"BitFlip", byteSliceBitFlip},
+	{"XORByte", byteSliceXORByte},
+	{"SwapByte", byteSliceSwapByte},
+	{"ArithmeticUint8", byteSliceArithmeticUint8},
+	{"ArithmeticUint16", byteSliceArithmeticUint16},
+	{"ArithmeticUint32", byteSliceArithmeticUint32},
+	{"ArithmeticUint64", byteSliceArithmeticUint64},
+	{"OverwriteInterestingUint8", byteSliceOverwriteInterestingUint8},
+	{"OverwriteInterestingUint16", byteSliceOverwriteInterestingUint16},
+	{"OverwriteInterestingUint32", byteSliceOverwriteInterestingUint32},
+	{"InsertConstantBytes", byteSliceInsertConstantBytes},
+	{"OverwriteConstantBytes", byteSliceOverwriteConstantBytes},
+	{"ShuffleBytes", byteSliceShuffleBytes},
+	{"SwapBytes", byteSliceSwapBytes},
+}
+
+// RemoveBytes removes a random chunk of bytes from b.
+// It returns nil if b is too short to remove anything.
+func (m *Mutator) RemoveBytes(b []byte) []byte {
+	return byteSliceRemoveBytes(m, b)
+}
+
+// MutateBytes returns a mutated copy of b together with the names of the applied
+// mutators. It stacks a random number of mutations, the result can grow up to the
+// maximum length of the mutator. The input is not modified.
+// Pass the names to Reward if the mutated input turns out to be interesting.
+func (m *Mutator) MutateBytes(b []byte) ([]byte, []string) {
+	// The mutators grow the slice within its capacity
+	res := make([]byte, len(b), max(len(b), m.maxLen))
+	copy(res, b)
+
+	var applied []string
+	for n := m.rand(maxStackedMutations) + 1; len(applied) < n; {
+		index := m.pick()
+		mut := byteSliceMutators[index]
+		if mutated := mut.mutate(m, res); mutated != nil {
+			res = mutated
+			applied = append(applied, mut.name)
+			m.used(index)
+		} else if len(res) == 0 && cap(res) <= 1 {
+			// Nothing can change an empty input without room to grow
+			break
 		}
 	}
+	return res, applied
 }
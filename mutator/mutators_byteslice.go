@@ -227,12 +227,21 @@ func byteSliceInsertConstantBytes(m *Mutator, b []byte) []byte {
 	// (libFuzzer sets a min/max based on the min/max size of
 	// entries in the corpus and then picks uniformly from
 	// that range).
-	n := m.chooseLen(4096)
+	// Half of the time insert an entry of the dictionary instead
+	entry := m.dictEntry()
+	n := len(entry)
+	if entry == nil || m.bool() {
+		entry, n = nil, m.chooseLen(4096)
+	}
 	if len(b)+n >= cap(b) {
 		return nil
 	}
 	b = b[:len(b)+n]
 	copy(b[dst+n:], b[dst:])
+	if entry != nil {
+		copy(b[dst:], entry)
+		return b
+	}
 	rb := byte(m.rand(256))
 	for i := dst; i < dst+n; i++ {
 		b[i] = rb
@@ -246,6 +255,11 @@ func byteSliceOverwriteConstantBytes(m *Mutator, b []byte) []byte {
 		return nil
 	}
 	dst := m.rand(len(b))
+	// Half of the time overwrite with an entry of the dictionary instead
+	if entry := m.dictEntry(); entry != nil && m.bool() {
+		copy(b[dst:], entry)
+		return b
+	}
 	n := m.chooseLen(len(b) - dst)
 	rb := byte(m.rand(256))
 	for i := dst; i < dst+n; i++ {
@@ -0,0 +1,424 @@
+package mutator
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/vm"
+)
+
+// instruction is an opcode together with its immediate.
+type instruction struct {
+	op  vm.OpCode
+	imm []byte
+	// target is the JUMPDEST a PUSH feeding a JUMP or JUMPI points to. The
+	// immediate is rewritten on assembly, so that static jumps survive mutations
+	// that move the JUMPDEST.
+	target *instruction
+}
+
+// definedOps are all opcodes known to the zvm.
+var definedOps []vm.OpCode
+
+// opClasses are groups of opcodes that take and return the same number of stack
+// items, so that swapping them keeps the stack layout of the program intact.
+var opClasses = [][]vm.OpCode{
+	{vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.EXP, vm.SIGNEXTEND,
+		vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR, vm.XOR, vm.BYTE,
+		vm.SHL, vm.SHR, vm.SAR, vm.KECCAK256},
+	{vm.ADDMOD, vm.MULMOD},
+	{vm.ISZERO, vm.NOT, vm.BALANCE, vm.CALLDATALOAD, vm.EXTCODESIZE, vm.EXTCODEHASH,
+		vm.BLOCKHASH, vm.MLOAD, vm.SLOAD},
+	{vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE,
+		vm.GASPRICE, vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER,
+		vm.PREVRANDAO, vm.GASLIMIT, vm.CHAINID, vm.SELFBALANCE, vm.BASEFEE,
+		vm.PC, vm.MSIZE, vm.GAS, vm.PUSH0},
+	{vm.MSTORE, vm.MSTORE8, vm.SSTORE},
+	{vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY},
+	{vm.DELEGATECALL, vm.STATICCALL},
+	{vm.RETURN, vm.REVERT},
+	{vm.STOP, vm.INVALID},
+}
+
+// opClass maps an opcode to its class in opClasses.
+var opClass = make(map[vm.OpCode][]vm.OpCode)
+
+func init() {
+	for i := 0; i < 256; i++ {
+		if op := vm.OpCode(i); !strings.HasPrefix(op.String(), "opcode ") {
+			definedOps = append(definedOps, op)
+		}
+	}
+	for _, class := range opClasses {
+		for _, op := range class {
+			opClass[op] = class
+		}
+	}
+	var dups, swaps []vm.OpCode
+	for i := 0; i < 16; i++ {
+		dups = append(dups, vm.DUP1+vm.OpCode(i))
+		swaps = append(swaps, vm.SWAP1+vm.OpCode(i))
+	}
+	for i := range dups {
+		opClass[dups[i]] = dups
+		opClass[swaps[i]] = swaps
+	}
+}
+
+// immediateSize returns the number of immediate bytes following op.
+func immediateSize(op vm.OpCode) int {
+	if op.IsPush() {
+		return int(op - vm.PUSH0)
+	}
+	return 0
+}
+
+// isTerminator reports whether op ends a basic block.
+func isTerminator(op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.JUMP, vm.JUMPI, vm.RETURN, vm.REVERT, vm.INVALID:
+		return true
+	}
+	return false
+}
+
+// parseCode splits code into instructions. A PUSH at the end of the code that is
+// cut short gets its immediate padded with zeros, just like the zvm reads it, so
+// that instructions appended later don't end up in the immediate.
+// PUSHes directly followed by a JUMP or JUMPI are linked to their JUMPDEST.
+func parseCode(code []byte) []*instruction {
+	var (
+		ins       []*instruction
+		jumpdests = make(map[uint64]*instruction)
+	)
+	for pc := 0; pc < len(code); {
+		op := vm.OpCode(code[pc])
+		end := pc + 1 + immediateSize(op)
+		imm := make([]byte, end-pc-1)
+		copy(imm, code[min(pc+1, len(code)):min(end, len(code))])
+		in := &instruction{op: op, imm: imm}
+		if op == vm.JUMPDEST {
+			jumpdests[uint64(pc)] = in
+		}
+		ins = append(ins, in)
+		pc = end
+	}
+	for i := 1; i < len(ins); i++ {
+		if (ins[i].op == vm.JUMP || ins[i].op == vm.JUMPI) && ins[i-1].op.IsPush() {
+			if dest := new(big.Int).SetBytes(ins[i-1].imm); dest.IsUint64() {
+				ins[i-1].target = jumpdests[dest.Uint64()]
+			}
+		}
+	}
+	return ins
+}
+
+// assembleCode is the inverse of parseCode. Linked PUSHes are updated to the new
+// location of their JUMPDEST and grow if the location doesn't fit anymore.
+func assembleCode(ins []*instruction) []byte {
+	var pcs map[*instruction]uint64
+	for grown := true; grown; {
+		grown = false
+		pcs = make(map[*instruction]uint64)
+		var pc uint64
+		for _, in := range ins {
+			pcs[in] = pc
+			pc += 1 + uint64(len(in.imm))
+		}
+		for _, in := range ins {
+			if in.target == nil {
+				continue
+			}
+			if dest, ok := pcs[in.target]; ok {
+				if size := len(new(big.Int).SetUint64(dest).Bytes()); size > len(in.imm) {
+					in.op, in.imm = vm.PUSH0+vm.OpCode(size), make([]byte, size)
+					grown = true
+				}
+			}
+		}
+	}
+	var code []byte
+	for _, in := range ins {
+		if dest, ok := pcs[in.target]; ok {
+			new(big.Int).SetUint64(dest).FillBytes(in.imm)
+		}
+		code = append(code, byte(in.op))
+		code = append(code, in.imm...)
+	}
+	return code
+}
+
+// TruncateCode returns the longest prefix of code that is at most size bytes long
+// and doesn't end within the immediate of a PUSH.
+func TruncateCode(code []byte, size int) []byte {
+	var end int
+	for pc := 0; pc < len(code); {
+		next := pc + 1 + immediateSize(vm.OpCode(code[pc]))
+		if next > size {
+			break
+		}
+		pc, end = next, min(next, len(code))
+	}
+	return code[:end]
+}
+
+// protected reports whether the instruction is part of the jump structure of the
+// program and must not be removed or replaced.
+func (in *instruction) protected() bool {
+	return in.op == vm.JUMPDEST || in.op == vm.JUMP || in.op == vm.JUMPI || in.target != nil
+}
+
+type codeMutator func(m *Mutator, ins []*instruction, others [][]byte) []*instruction
+
+var codeMutators = []codeMutator{
+	codeInsertInstruction,
+	codeReplaceInstruction,
+	codeRemoveInstruction,
+	codeSwapOpcode,
+	codeInsertSequence,
+	codeTweakPush,
+	codeSpliceBlock,
+	codeInsertDictionary,
+}
+
+// MutateCode returns a mutated copy of the bytecode. It works on whole instructions,
+// so that PUSH immediates stay aligned and no immediate turns into opcodes or vice
+// versa. JUMPDESTs are never removed and static jumps follow their JUMPDEST if it
+// moves. Basic blocks of the other programs can be spliced into the code.
+func (m *Mutator) MutateCode(code []byte, others ...[]byte) []byte {
+	ins := parseCode(code)
+	for {
+		mut := codeMutators[m.rand(len(codeMutators))]
+		if res := mut(m, ins, others); res != nil {
+			return assembleCode(res)
+		}
+	}
+}
+
+// unprotected returns the index of a random instruction matching the filter that
+// is not protected or -1 if there is none.
+func (m *Mutator) unprotected(ins []*instruction, filter func(*instruction) bool) int {
+	var candidates []int
+	for i, in := range ins {
+		if !in.protected() && filter(in) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	return candidates[m.rand(len(candidates))]
+}
+
+func anyInstruction(*instruction) bool { return true }
+
+// insertPosition returns a random position that doesn't separate a static jump
+// from the PUSH of its destination.
+func (m *Mutator) insertPosition(ins []*instruction) int {
+	for {
+		if pos := m.rand(len(ins) + 1); pos == 0 || ins[pos-1].target == nil {
+			return pos
+		}
+	}
+}
+
+func insertInstructions(ins []*instruction, pos int, seq ...*instruction) []*instruction {
+	res := make([]*instruction, 0, len(ins)+len(seq))
+	res = append(res, ins[:pos]...)
+	res = append(res, seq...)
+	return append(res, ins[pos:]...)
+}
+
+func codeInsertInstruction(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	return insertInstructions(ins, m.insertPosition(ins), m.randomInstruction())
+}
+
+func codeReplaceInstruction(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	i := m.unprotected(ins, anyInstruction)
+	if i < 0 {
+		return nil
+	}
+	ins[i] = m.randomInstruction()
+	return ins
+}
+
+func codeRemoveInstruction(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	i := m.unprotected(ins, anyInstruction)
+	if i < 0 {
+		return nil
+	}
+	return append(ins[:i], ins[i+1:]...)
+}
+
+// codeSwapOpcode replaces an opcode with one of the same class, e.g. ADD with SUB.
+// PUSHes change their size, keeping the low bytes of the value.
+func codeSwapOpcode(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	i := m.unprotected(ins, func(in *instruction) bool {
+		return opClass[in.op] != nil || in.op.IsPush() && in.op != vm.PUSH0
+	})
+	if i < 0 {
+		return nil
+	}
+	in := ins[i]
+	if class := opClass[in.op]; class != nil {
+		in.op = class[m.rand(len(class))]
+		return ins
+	}
+	size := m.rand(32) + 1
+	imm := make([]byte, size)
+	copy(imm[max(size-len(in.imm), 0):], in.imm[max(len(in.imm)-size, 0):])
+	in.op, in.imm = vm.PUSH0+vm.OpCode(size), imm
+	return ins
+}
+
+// codeTweakPush mutates the value of a PUSH, keeping its size. The new value can
+// be an entry of the dictionary.
+func codeTweakPush(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	i := m.unprotected(ins, func(in *instruction) bool { return len(in.imm) != 0 })
+	if i < 0 {
+		return nil
+	}
+	in := ins[i]
+	v := m.dictEntry()
+	if v == nil || m.bool() {
+		v = m.MutateUint256(new(big.Int).SetBytes(in.imm)).Bytes()
+	}
+	clear(in.imm)
+	copy(in.imm[max(len(in.imm)-len(v), 0):], v[max(len(v)-len(in.imm), 0):])
+	return ins
+}
+
+// codeInsertSequence inserts a state accessing instruction together with PUSHes of
+// sensible stack arguments.
+func codeInsertSequence(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	var (
+		small   = func() *instruction { return pushInt(int64(m.rand(64))) }
+		slot    = func() *instruction { return pushBytes(m.MutateHash(common.Hash{}).Bytes()) }
+		address = func() *instruction { return pushBytes(m.MutateAddress(common.Address{}).Bytes()) }
+		value   = func() *instruction { return pushBytes(m.MutateUint256(nil).Bytes()) }
+		op      = func(op vm.OpCode) *instruction { return &instruction{op: op} }
+		seq     []*instruction
+	)
+	switch m.rand(6) {
+	case 0:
+		seq = []*instruction{value(), slot(), op(vm.SSTORE)}
+	case 1:
+		seq = []*instruction{slot(), op(vm.SLOAD), op(vm.POP)}
+	case 2:
+		// retSize, retOffset, argsSize, argsOffset, value, address, gas
+		seq = []*instruction{small(), small(), small(), small(), pushInt(int64(m.rand(2))), address(), op(vm.GAS), op(vm.CALL), op(vm.POP)}
+	case 3:
+		call := []vm.OpCode{vm.DELEGATECALL, vm.STATICCALL}[m.rand(2)]
+		seq = []*instruction{small(), small(), small(), small(), address(), op(vm.GAS), op(call), op(vm.POP)}
+	case 4:
+		// salt, size, offset, value
+		seq = []*instruction{value(), small(), small(), pushInt(0), op(vm.CREATE2), op(vm.POP)}
+	default:
+		// size, offset, value
+		seq = []*instruction{small(), small(), pushInt(0), op(vm.CREATE), op(vm.POP)}
+	}
+	return insertInstructions(ins, m.blockBoundary(ins), seq...)
+}
+
+// codeSpliceBlock inserts a basic block of another program at a block boundary.
+func codeSpliceBlock(m *Mutator, ins []*instruction, others [][]byte) []*instruction {
+	if len(others) == 0 {
+		return nil
+	}
+	blocks := basicBlocks(parseCode(others[m.rand(len(others))]))
+	if len(blocks) == 0 {
+		return nil
+	}
+	return insertInstructions(ins, m.blockBoundary(ins), copyBlock(blocks[m.rand(len(blocks))])...)
+}
+
+// codeInsertDictionary inserts an entry of the dictionary as instructions at a
+// block boundary.
+func codeInsertDictionary(m *Mutator, ins []*instruction, _ [][]byte) []*instruction {
+	entry := m.dictEntry()
+	if entry == nil {
+		return nil
+	}
+	// Jumps within the entry are kept, the inserted instructions are new
+	return insertInstructions(ins, m.blockBoundary(ins), parseCode(entry)...)
+}
+
+// blockBoundary returns a random position between two basic blocks.
+func (m *Mutator) blockBoundary(ins []*instruction) int {
+	boundaries := []int{0}
+	for i, in := range ins {
+		if in.op == vm.JUMPDEST && i != 0 {
+			boundaries = append(boundaries, i)
+		}
+		if isTerminator(in.op) {
+			boundaries = append(boundaries, i+1)
+		}
+	}
+	if boundaries[len(boundaries)-1] != len(ins) {
+		boundaries = append(boundaries, len(ins))
+	}
+	return boundaries[m.rand(len(boundaries))]
+}
+
+// basicBlocks splits the instructions into basic blocks, which start at the
+// beginning of the code or a JUMPDEST and end with a terminator.
+func basicBlocks(ins []*instruction) [][]*instruction {
+	var (
+		blocks [][]*instruction
+		start  int
+	)
+	for i, in := range ins {
+		if in.op == vm.JUMPDEST && i != start {
+			blocks = append(blocks, ins[start:i])
+			start = i
+		}
+		if isTerminator(in.op) {
+			blocks = append(blocks, ins[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(ins) {
+		blocks = append(blocks, ins[start:])
+	}
+	return blocks
+}
+
+// copyBlock copies the instructions of a block. Jumps into the block itself are
+// kept, jumps to other blocks become dynamic.
+func copyBlock(block []*instruction) []*instruction {
+	copies := make(map[*instruction]*instruction)
+	res := make([]*instruction, len(block))
+	for i, in := range block {
+		res[i] = &instruction{op: in.op, imm: append([]byte{}, in.imm...)}
+		copies[in] = res[i]
+	}
+	for i, in := range block {
+		res[i].target = copies[in.target]
+	}
+	return res
+}
+
+// pushBytes returns the shortest PUSH of the value.
+func pushBytes(v []byte) *instruction {
+	v = new(big.Int).SetBytes(v).Bytes()
+	return &instruction{op: vm.PUSH0 + vm.OpCode(len(v)), imm: v}
+}
+
+func pushInt(v int64) *instruction {
+	return pushBytes(big.NewInt(v).Bytes())
+}
+
+// randomInstruction returns a random defined opcode. PUSHes get a full immediate,
+// which is an interesting value half of the time.
+func (m *Mutator) randomInstruction() *instruction {
+	op := definedOps[m.rand(len(definedOps))]
+	imm := make([]byte, immediateSize(op))
+	if m.bool() {
+		m.r.Read(imm)
+	} else {
+		v := interesting256[m.rand(len(interesting256))].Bytes()
+		copy(imm[max(len(imm)-len(v), 0):], v[max(len(v)-len(imm), 0):])
+	}
+	return &instruction{op: op, imm: imm}
+}
@@ -0,0 +1,62 @@
+package mutator
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/params"
+)
+
+// TestOpClassArity checks against the jump table of the zvm that opcodes are
+// only swapped with opcodes of the same stack arity.
+func TestOpClassArity(t *testing.T) {
+	jt, err := vm.LookupInstructionSet(params.Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, class := range opClasses {
+		wantMin, wantMax := jt[class[0]].Stack()
+		for _, op := range class[1:] {
+			if haveMin, haveMax := jt[op].Stack(); haveMin != wantMin || haveMax != wantMax {
+				t.Errorf("%v: stack %v/%v differs from %v: %v/%v", op, haveMin, haveMax, class[0], wantMin, wantMax)
+			}
+		}
+	}
+	// DUPs and SWAPs reach to different depths but have the same effect on the stack size
+	for op, class := range opClass {
+		_, wantMax := jt[op].Stack()
+		for _, other := range class {
+			if _, haveMax := jt[other].Stack(); haveMax != wantMax {
+				t.Errorf("%v: stack size changes differently than with %v", other, op)
+			}
+		}
+	}
+}
+
+func TestFork(t *testing.T) {
+	code := []byte{0x60, 0x01, 0x60, 0x02, 0x01, 0x00}
+	newForks := func() (*Mutator, *Mutator) {
+		m := NewMutator(rand.New(rand.NewSource(1)))
+		m.SetDictionary([][]byte{{0xde, 0xad}})
+		return m.Fork(), m.Fork()
+	}
+	a1, a2 := newForks()
+	b1, b2 := newForks()
+	if a1.dict == nil || a2.dict == nil {
+		t.Fatal("dictionary not forked")
+	}
+	differs := false
+	for i := 0; i < 100; i++ {
+		x, y := a1.MutateCode(code), b1.MutateCode(code)
+		if !bytes.Equal(x, y) {
+			t.Fatalf("mutation %d of the same seed differs: %x != %x", i, x, y)
+		}
+		differs = differs || !bytes.Equal(x, a2.MutateCode(code))
+		b2.MutateCode(code)
+	}
+	if !differs {
+		t.Error("forks of the same mutator mutate identically")
+	}
+}
@@ -0,0 +1,156 @@
+package mutator
+
+import (
+	"math"
+	"math/big"
+	"slices"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/crypto"
+)
+
+// maxDelta is the maximum value added to or subtracted from integers.
+const maxDelta = 35
+
+var (
+	interesting64 = []uint64{
+		0, 1, 2, 31, 32, 33, 63, 64, 255, 256,
+		math.MaxInt32, math.MaxInt32 + 1, math.MaxUint32, math.MaxUint32 + 1,
+		math.MaxInt64, math.MaxInt64 + 1, math.MaxUint64 - 1, math.MaxUint64,
+	}
+
+	tt256  = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m = new(big.Int).Sub(tt256, big.NewInt(1))
+
+	interesting256 []*big.Int
+
+	// precompiles are the precompile addresses in ascending order, the order of
+	// vm.PrecompiledAddressesBerlin differs between runs as it is built from a map.
+	precompiles = sortedPrecompiles()
+)
+
+func init() {
+	for _, v := range interesting64 {
+		interesting256 = append(interesting256, new(big.Int).SetUint64(v))
+	}
+	for _, bit := range []uint{64, 128, 160, 255} {
+		p := new(big.Int).Lsh(big.NewInt(1), bit)
+		interesting256 = append(interesting256, p, new(big.Int).Sub(p, big.NewInt(1)))
+	}
+	interesting256 = append(interesting256,
+		new(big.Int).Sub(tt256, big.NewInt(2)), // max-1
+		new(big.Int).Set(tt256m),               // max, -1 in two's complement
+	)
+}
+
+// Precompiles returns the precompile addresses in ascending order.
+func Precompiles() []common.Address {
+	return slices.Clone(precompiles)
+}
+
+func sortedPrecompiles() []common.Address {
+	addrs := slices.Clone(vm.PrecompiledAddressesBerlin)
+	slices.SortFunc(addrs, func(a, b common.Address) int { return a.Cmp(b) })
+	return addrs
+}
+
+// MutateUint64 returns a mutation of v: an interesting value, a power of two,
+// a small addition or subtraction or v with a bit flipped.
+func (m *Mutator) MutateUint64(v uint64) uint64 {
+	switch m.rand(5) {
+	case 0:
+		return interesting64[m.rand(len(interesting64))]
+	case 1:
+		return uint64(1)<<m.rand(64) + uint64(m.rand(3)) - 1
+	case 2:
+		return v + uint64(m.rand(maxDelta)) + 1
+	case 3:
+		return v - uint64(m.rand(maxDelta)) - 1
+	default:
+		return v ^ uint64(1)<<m.rand(64)
+	}
+}
+
+// MutateUint256 returns a mutation of the 256-bit integer v: an interesting value
+// like 2^255 or max-1, a power of two, a small addition or subtraction or v with
+// a bit flipped. The result is always in [0, 2^256).
+func (m *Mutator) MutateUint256(v *big.Int) *big.Int {
+	res := new(big.Int)
+	if v != nil {
+		res.And(v, tt256m)
+	}
+	switch m.rand(5) {
+	case 0:
+		res.Set(interesting256[m.rand(len(interesting256))])
+	case 1:
+		res.Lsh(big.NewInt(1), uint(m.rand(256)))
+		res.Add(res, big.NewInt(int64(m.rand(3)-1)))
+	case 2:
+		res.Add(res, big.NewInt(int64(m.rand(maxDelta)+1)))
+	case 3:
+		res.Sub(res, big.NewInt(int64(m.rand(maxDelta)+1)))
+	default:
+		bit := m.rand(256)
+		res.SetBit(res, bit, res.Bit(bit)^1)
+	}
+	// Wrap around like the EVM does
+	return res.And(res, tt256m)
+}
+
+// MutateAddress returns a mutation of addr: the zero address, a precompile,
+// one of the known addresses, e.g. the sender or recently created contracts,
+// a random address or addr with a bit flipped.
+func (m *Mutator) MutateAddress(addr common.Address, known ...common.Address) common.Address {
+	switch m.rand(6) {
+	case 0:
+		return common.Address{}
+	case 1:
+		return precompiles[m.rand(len(precompiles))]
+	case 2:
+		if len(known) != 0 {
+			return known[m.rand(len(known))]
+		}
+		fallthrough
+	case 3:
+		var res common.Address
+		m.r.Read(res[:])
+		return res
+	case 4:
+		// The neighbour of addr, e.g. the next precompile
+		res := addr
+		res[common.AddressLength-1] += byte(m.rand(3)) - 1
+		return res
+	default:
+		res := addr
+		pos := m.rand(common.AddressLength)
+		res[pos] ^= 1 << m.rand(8)
+		return res
+	}
+}
+
+// MutateHash returns a mutation of the hash or storage key h: a small slot, the
+// location of a mapping or dynamic array, an interesting 256-bit value, a random
+// hash or h with a bit flipped.
+func (m *Mutator) MutateHash(h common.Hash) common.Hash {
+	switch m.rand(5) {
+	case 0:
+		return common.BigToHash(big.NewInt(int64(m.rand(8))))
+	case 1:
+		// Solidity stores dynamic data at keccak(slot) + index
+		loc := crypto.Keccak256Hash(common.BigToHash(big.NewInt(int64(m.rand(8)))).Bytes()).Big()
+		loc.Add(loc, big.NewInt(int64(m.rand(4))))
+		return common.BigToHash(loc.And(loc, tt256m))
+	case 2:
+		return common.BigToHash(interesting256[m.rand(len(interesting256))])
+	case 3:
+		var res common.Hash
+		m.r.Read(res[:])
+		return res
+	default:
+		res := h
+		pos := m.rand(common.HashLength)
+		res[pos] ^= 1 << m.rand(8)
+		return res
+	}
+}
@@ -0,0 +1,100 @@
+package mutator
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+)
+
+func TestMutateUint64(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	seen := make(map[uint64]bool)
+	changed := 0
+	for i := 0; i < 10_000; i++ {
+		v := m.MutateUint64(1000)
+		seen[v] = true
+		if v != 1000 {
+			changed++
+		}
+	}
+	for _, v := range interesting64 {
+		if !seen[v] {
+			t.Errorf("interesting value %v never produced", v)
+		}
+	}
+	if changed < 9_000 {
+		t.Errorf("too few changed values: %v of 10000", changed)
+	}
+}
+
+func TestMutateUint256(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	seen := make(map[string]bool)
+	inputs := []*big.Int{nil, big.NewInt(0), big.NewInt(-1), new(big.Int).Set(tt256m), new(big.Int).Lsh(tt256, 1)}
+	for i := 0; i < 10_000; i++ {
+		v := m.MutateUint256(inputs[i%len(inputs)])
+		if v.Sign() < 0 || v.Cmp(tt256) >= 0 {
+			t.Fatalf("result out of range: %v", v)
+		}
+		seen[v.String()] = true
+	}
+	for _, v := range interesting256 {
+		if !seen[v.String()] {
+			t.Errorf("interesting value %v never produced", v)
+		}
+	}
+	// The input is not modified
+	v := big.NewInt(42)
+	m.MutateUint256(v)
+	if v.Int64() != 42 {
+		t.Errorf("input modified: %v", v)
+	}
+}
+
+func TestMutateAddress(t *testing.T) {
+	var (
+		m     = NewMutator(rand.New(rand.NewSource(1)))
+		addr  = common.Address{1, 2, 3}
+		known = common.Address{0xaa}
+		seen  = make(map[common.Address]bool)
+	)
+	for i := 0; i < 10_000; i++ {
+		seen[m.MutateAddress(addr, known)] = true
+	}
+	for _, want := range append([]common.Address{{}, known}, precompiles...) {
+		if !seen[want] {
+			t.Errorf("address %v never produced", want)
+		}
+	}
+	if !slices.IsSortedFunc(precompiles, func(a, b common.Address) int { return a.Cmp(b) }) {
+		t.Errorf("precompiles not sorted: %v", precompiles)
+	}
+	// The same seed produces the same addresses
+	a, b := NewMutator(rand.New(rand.NewSource(2))), NewMutator(rand.New(rand.NewSource(2)))
+	for i := 0; i < 100; i++ {
+		if x, y := a.MutateAddress(addr), b.MutateAddress(addr); x != y {
+			t.Fatalf("mutation %d differs: %v != %v", i, x, y)
+		}
+	}
+}
+
+func TestMutateHash(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	seen := make(map[common.Hash]bool)
+	for i := 0; i < 10_000; i++ {
+		seen[m.MutateHash(common.Hash{0xff})] = true
+	}
+	for i := 0; i < 8; i++ {
+		if slot := common.BigToHash(big.NewInt(int64(i))); !seen[slot] {
+			t.Errorf("slot %v never produced", slot)
+		}
+	}
+	for _, v := range interesting256 {
+		if !seen[common.BigToHash(v)] {
+			t.Errorf("interesting value %v never produced", v)
+		}
+	}
+}
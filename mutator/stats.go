@@ -0,0 +1,94 @@
+package mutator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explorationRate is the share of mutators picked uniformly at random when
+// scheduling adaptively, so that unlucky mutators still get a chance.
+const explorationRate = 4 // 1 in 4
+
+// MutatorStats are the statistics of a byte slice mutator.
+type MutatorStats struct {
+	Name      string
+	Used      uint64 // number of times the mutator changed an input
+	Succeeded uint64 // number of times an input it changed was rewarded
+}
+
+// MutationStats are the statistics of all byte slice mutators.
+type MutationStats []MutatorStats
+
+func (s MutationStats) String() string {
+	var b strings.Builder
+	b.WriteString("mutators:")
+	for _, st := range s {
+		if st.Used == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n  %-27v used: %-8v succeeded: %-8v rate: %.3f", st.Name, st.Used, st.Succeeded, float64(st.Succeeded)/float64(st.Used))
+	}
+	return b.String()
+}
+
+// SetAdaptive enables adaptive scheduling. Similar to MOpt, mutators are picked
+// proportionally to their success rate instead of uniformly at random.
+func (m *Mutator) SetAdaptive(adaptive bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.adaptive = adaptive
+}
+
+// Reward marks the mutators applied to an input as successful, e.g. because the
+// input produced a new outcome or new coverage. It is safe for concurrent use.
+func (m *Mutator) Reward(applied []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range applied {
+		for i := range m.stats {
+			if m.stats[i].Name == name {
+				m.stats[i].Succeeded++
+			}
+		}
+	}
+}
+
+// Stats returns a copy of the statistics of the byte slice mutators.
+func (m *Mutator) Stats() MutationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append(MutationStats{}, m.stats...)
+}
+
+// pick returns the index of the next byte slice mutator to try.
+func (m *Mutator) pick() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.adaptive || m.rand(explorationRate) == 0 {
+		return m.rand(len(byteSliceMutators))
+	}
+	// Weight by the success rate, smoothed so that unused mutators start at 1/2
+	var (
+		weights = make([]float64, len(m.stats))
+		total   float64
+	)
+	for i, st := range m.stats {
+		weights[i] = float64(st.Succeeded+1) / float64(st.Used+2)
+		total += weights[i]
+	}
+	pick := m.r.Float64() * total
+	for i, w := range weights {
+		if pick < w {
+			return i
+		}
+		pick -= w
+	}
+	return len(weights) - 1
+}
+
+// used records that the byte slice mutator with the index changed an input.
+func (m *Mutator) used(index int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats[index].Used++
+}
@@ -0,0 +1,53 @@
+package mutator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMutatorStats(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	var used uint64
+	for i := 0; i < 100; i++ {
+		_, applied := m.MutateBytes([]byte("tx-fuzz"))
+		used += uint64(len(applied))
+		if i%2 == 0 {
+			m.Reward(applied)
+		}
+	}
+	var have, succeeded uint64
+	for _, st := range m.Stats() {
+		have += st.Used
+		succeeded += st.Succeeded
+		if st.Succeeded > st.Used {
+			t.Errorf("%v: more successes than uses: %v > %v", st.Name, st.Succeeded, st.Used)
+		}
+	}
+	if have != used {
+		t.Fatalf("wrong number of uses: have %v want %v", have, used)
+	}
+	if succeeded == 0 || succeeded == used {
+		t.Fatalf("unexpected number of successes: %v of %v", succeeded, used)
+	}
+}
+
+func TestAdaptiveScheduling(t *testing.T) {
+	m := NewMutator(rand.New(rand.NewSource(1)))
+	m.SetAdaptive(true)
+	// Only BitFlip ever succeeds
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		_, applied := m.MutateBytes(make([]byte, 64))
+		for _, name := range applied {
+			counts[name]++
+			if name == "BitFlip" {
+				m.Reward([]string{name})
+			}
+		}
+	}
+	for name, n := range counts {
+		if name != "BitFlip" && n >= counts["BitFlip"] {
+			t.Errorf("%v picked as often as the successful mutator: %v >= %v", name, n, counts["BitFlip"])
+		}
+	}
+}
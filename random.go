@@ -3,9 +3,11 @@ package txfuzz
 import (
 	"crypto/rand"
 	"fmt"
+	"math/big"
 	mathRand "math/rand"
 
 	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/params"
 )
 
 const (
@@ -42,6 +44,17 @@ func randomAddress() common.Address {
 	return common.Address{}
 }
 
+// randomValue returns a small value to send along with a call, zero most of the time.
+func randomValue() *big.Int {
+	switch mathRand.Int31n(4) {
+	case 0:
+		return big.NewInt(1)
+	case 1:
+		return big.NewInt(mathRand.Int63n(params.GWei))
+	}
+	return big.NewInt(0)
+}
+
 func randomBlobData() ([]byte, error) {
 	size := mathRand.Intn(maxDataPerTx)
 	data := make([]byte, size)
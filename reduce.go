@@ -0,0 +1,162 @@
+package txfuzz
+
+import (
+	"math/big"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+)
+
+// Oracle reports whether a transaction still triggers a failure,
+// e.g. by running it against a fresh node and checking whether it crashed.
+type Oracle func(tx *types.DynamicFeeTx) (bool, error)
+
+// Reduce shrinks the calldata or initcode, the access list and the fields of a
+// failing transaction as long as the oracle reports that the failure reproduces.
+// The passed transaction needs to reproduce the failure.
+func Reduce(tx *types.DynamicFeeTx, oracle Oracle) (*types.DynamicFeeTx, error) {
+	cur := copyDynamicFeeTx(tx)
+	// Repeat until a fixpoint is reached, as a smaller access list can
+	// allow a smaller calldata and vice versa.
+	for {
+		size := txSize(cur)
+		data, err := ddmin(cur.Data, func(data []byte) (bool, error) {
+			candidate := copyDynamicFeeTx(cur)
+			candidate.Data = data
+			return oracle(candidate)
+		})
+		if err != nil {
+			return nil, err
+		}
+		cur.Data = data
+
+		al, err := ddmin(cur.AccessList, func(al []types.AccessTuple) (bool, error) {
+			candidate := copyDynamicFeeTx(cur)
+			candidate.AccessList = al
+			return oracle(candidate)
+		})
+		if err != nil {
+			return nil, err
+		}
+		cur.AccessList = al
+
+		for i := range cur.AccessList {
+			keys, err := ddmin(cur.AccessList[i].StorageKeys, func(keys []common.Hash) (bool, error) {
+				candidate := copyDynamicFeeTx(cur)
+				candidate.AccessList[i].StorageKeys = keys
+				return oracle(candidate)
+			})
+			if err != nil {
+				return nil, err
+			}
+			cur.AccessList[i].StorageKeys = keys
+		}
+
+		if cur, err = reduceFields(cur, oracle); err != nil {
+			return nil, err
+		}
+		if txSize(cur) >= size {
+			return cur, nil
+		}
+	}
+}
+
+// reduceFields tries to zero the value and to halve the gas limit of the transaction.
+// The gas limit is not reduced below the intrinsic gas, as the pool rejects such
+// transactions anyway.
+func reduceFields(tx *types.DynamicFeeTx, oracle Oracle) (*types.DynamicFeeTx, error) {
+	if tx.Value != nil && tx.Value.Sign() != 0 {
+		candidate := copyDynamicFeeTx(tx)
+		candidate.Value = new(big.Int)
+		ok, err := oracle(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tx = candidate
+		}
+	}
+	intrinsic, err := core.IntrinsicGas(tx.Data, tx.AccessList, tx.To == nil)
+	if err != nil {
+		return nil, err
+	}
+	for tx.Gas > intrinsic {
+		candidate := copyDynamicFeeTx(tx)
+		candidate.Gas = max(tx.Gas/2, intrinsic)
+		ok, err := oracle(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		tx = candidate
+	}
+	return tx, nil
+}
+
+// ddmin is the delta debugging minimization algorithm. It returns a minimal
+// subsequence of input for which test still holds.
+func ddmin[T any](input []T, test func([]T) (bool, error)) ([]T, error) {
+	n := 2
+	for len(input) >= 2 {
+		chunk := (len(input) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(input); start += chunk {
+			end := min(start+chunk, len(input))
+			// Try the complement of the chunk
+			complement := make([]T, 0, len(input)-(end-start))
+			complement = append(complement, input[:start]...)
+			complement = append(complement, input[end:]...)
+			ok, err := test(complement)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				input = complement
+				n = max(n-1, 2)
+				reduced = true
+				break
+			}
+		}
+		if !reduced {
+			if n >= len(input) {
+				break
+			}
+			n = min(n*2, len(input))
+		}
+	}
+	if len(input) == 1 {
+		ok, err := test(input[:0])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return input[:0], nil
+		}
+	}
+	return input, nil
+}
+
+// txSize is the measure the reduction minimizes.
+func txSize(tx *types.DynamicFeeTx) int {
+	return len(tx.Data) + len(tx.AccessList) + tx.AccessList.StorageKeys() + bitLen(tx.Value) + bitLen(new(big.Int).SetUint64(tx.Gas))
+}
+
+func bitLen(b *big.Int) int {
+	if b == nil {
+		return 0
+	}
+	return b.BitLen()
+}
+
+func copyDynamicFeeTx(tx *types.DynamicFeeTx) *types.DynamicFeeTx {
+	cpy := *tx
+	cpy.Data = append([]byte{}, tx.Data...)
+	cpy.AccessList = copyAccessList(tx.AccessList)
+	if tx.Value != nil {
+		cpy.Value = new(big.Int).Set(tx.Value)
+	}
+	return &cpy
+}
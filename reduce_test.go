@@ -0,0 +1,48 @@
+package txfuzz
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+)
+
+func TestReduce(t *testing.T) {
+	tx := &types.DynamicFeeTx{
+		Gas:   1_000_000,
+		To:    &common.Address{1},
+		Value: big.NewInt(100),
+		Data:  []byte("some calldata with a \xef in it"),
+		AccessList: types.AccessList{
+			{Address: common.Address{2}, StorageKeys: []common.Hash{{1}, {2}}},
+		},
+	}
+	// The failure reproduces as long as the calldata contains 0xef
+	oracle := func(tx *types.DynamicFeeTx) (bool, error) {
+		intrinsic, err := core.IntrinsicGas(tx.Data, tx.AccessList, tx.To == nil)
+		if err != nil {
+			return false, err
+		}
+		if tx.Gas < intrinsic {
+			t.Fatalf("oracle called with gas %v below the intrinsic gas %v", tx.Gas, intrinsic)
+		}
+		return bytes.Contains(tx.Data, []byte{0xef}), nil
+	}
+	reduced, err := Reduce(tx, oracle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reduced.Data, []byte{0xef}) {
+		t.Errorf("data not reduced: %x", reduced.Data)
+	}
+	if len(reduced.AccessList) != 0 || reduced.Value.Sign() != 0 {
+		t.Errorf("access list or value not reduced: %v %v", reduced.AccessList, reduced.Value)
+	}
+	intrinsic, _ := core.IntrinsicGas(reduced.Data, nil, false)
+	if reduced.Gas != intrinsic {
+		t.Errorf("gas not reduced to the intrinsic gas: have %v want %v", reduced.Gas, intrinsic)
+	}
+}
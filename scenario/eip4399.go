@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/vm"
+)
+
+// prevRandaoBlocks is the number of blocks in which PREVRANDAO is verified.
+const prevRandaoBlocks = 16
+
+func init() {
+	// PREVRANDAO, PUSH0, SSTORE
+	initcode := []byte{byte(vm.PREVRANDAO), byte(vm.PUSH0), byte(vm.SSTORE)}
+	var steps []Step
+	for i := 0; i < prevRandaoBlocks; i++ {
+		steps = append(steps, Step{
+			Name:    fmt.Sprintf("store prevrandao %d", i),
+			Data:    initcode,
+			Outcome: Success,
+			Check:   checkPrevRandao,
+		})
+	}
+	register(&Scenario{
+		Name:        "eip4399",
+		Description: "PREVRANDAO (EIP-4399) returns the prevRandao of the block header",
+		Steps:       steps,
+	})
+}
+
+// checkPrevRandao compares the value the initcode stored in slot 0 against the
+// prevRandao (formerly mixHash) of the header of the inclusion block.
+func checkPrevRandao(env *Env) error {
+	stored, err := env.Backend.StorageAt(env.Ctx, env.Receipt.ContractAddress, common.Hash{}, env.Receipt.BlockNumber)
+	if err != nil {
+		return err
+	}
+	header, err := env.Backend.HeaderByNumber(env.Ctx, env.Receipt.BlockNumber)
+	if err != nil {
+		return err
+	}
+	if header.Hash() != env.Receipt.BlockHash {
+		return fmt.Errorf("block %v was reorged, have %x want %x", env.Receipt.BlockNumber, header.Hash(), env.Receipt.BlockHash)
+	}
+	// A zero prevRandao would be indistinguishable from an unimplemented opcode
+	if header.Random == (common.Hash{}) {
+		return fmt.Errorf("block %v has no prevRandao", env.Receipt.BlockNumber)
+	}
+	if common.BytesToHash(stored) != header.Random {
+		return fmt.Errorf("block %v: PREVRANDAO returned %x, header has prevRandao %x", env.Receipt.BlockNumber, stored, header.Random)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/crypto"
+	"github.com/theQRL/gozvmlab/ops"
+	"github.com/theQRL/gozvmlab/program"
+)
+
+func init() {
+	register(&Scenario{
+		Name:        "london",
+		Description: "Rejection of new code starting with 0xEF (EIP-3541)",
+		Steps: []Step{
+			// A failed creation consumes all the gas it got, so only one per transaction
+			{Name: "create ef code", Data: efByte(false), Outcome: Success, Check: checkNoEfCode(false)},
+			{Name: "create2 ef code", Data: efByte(true), Outcome: Success, Check: checkNoEfCode(true)},
+			{Name: "deploy ef code", Data: efInitcode(), Outcome: Failure},
+		},
+	})
+}
+
+// efInitcode returns initcode deploying the code 0xEF.
+func efInitcode() []byte {
+	inner := []byte{
+		0xEF,
+	}
+
+	initcode := program.NewProgram()
+	initcode.Mstore(inner, 0)
+	initcode.Return(0, uint32(len(inner)))
+	return initcode.Bytecode()
+}
+
+// efByte returns initcode that tries to deploy the code 0xEF via CREATE or CREATE2.
+func efByte(isCreate2 bool) []byte {
+	program := program.NewProgram()
+	create(program, efInitcode(), false, isCreate2)
+	program.Op(ops.POP)
+	return program.Bytecode()
+}
+
+// checkNoEfCode verifies that the contract efByte tried to create doesn't exist.
+func checkNoEfCode(isCreate2 bool) func(env *Env) error {
+	return func(env *Env) error {
+		creator := env.Receipt.ContractAddress
+		// Contracts start with nonce 1, CREATE2 uses salt 0
+		addr := crypto.CreateAddress(creator, 1)
+		if isCreate2 {
+			addr = crypto.CreateAddress2(creator, common.Hash{}, crypto.Keccak256(efInitcode()))
+		}
+		code, err := env.Backend.CodeAt(env.Ctx, addr, env.Receipt.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if len(code) != 0 {
+			return fmt.Errorf("code starting with 0xEF deployed at %x: %x", addr, code)
+		}
+		return nil
+	}
+}
+
+func create(p *program.Program, code []byte, inMemory bool, isCreate2 bool) {
+	var (
+		value    = 0
+		offset   = 0
+		size     = len(code)
+		salt     = 0
+		createOp = ops.CREATE
+	)
+	// Load the code into mem
+	if !inMemory {
+		p.Mstore(code, 0)
+	}
+	// Create it
+	if isCreate2 {
+		p.Push(salt)
+		createOp = ops.CREATE2
+	}
+	p.Push(size).Push(offset).Push(value).Op(createOp)
+}
@@ -0,0 +1,206 @@
+// Package scenario contains deterministic tests of hard fork features. Every
+// scenario is a list of transactions together with the outcome they must have
+// on a node implementing the fork correctly.
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/accounts/abi/bind"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+const (
+	// defaultGas is the gas limit of steps that don't specify one.
+	defaultGas = 500_000
+	// receiptTimeout is how long to wait for the receipt of a step.
+	receiptTimeout = 2 * time.Minute
+)
+
+// Outcome is the expected outcome of a transaction.
+type Outcome int
+
+const (
+	Success  Outcome = iota // included with a successful receipt
+	Failure                 // included with a failed receipt
+	Rejected                // rejected when sending it to the node
+	Any                     // not asserted
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Success:
+		return "success"
+	case Failure:
+		return "failure"
+	case Rejected:
+		return "rejected"
+	default:
+		return "any"
+	}
+}
+
+// Scenario is a named test of a hard fork feature.
+type Scenario struct {
+	Name        string
+	Description string
+	Steps       []Step
+}
+
+// Step is a transaction of a scenario together with its expected outcome.
+type Step struct {
+	Name    string
+	To      *common.Address // recipient, nil deploys Data as initcode
+	Data    []byte
+	Value   *big.Int
+	Gas     uint64  // gas limit, 0 = defaultGas
+	Outcome Outcome // expected outcome
+	// Check is an optional assertion on the state after the transaction got
+	// included. It is not run for rejected transactions.
+	Check func(env *Env) error
+}
+
+// Env is the environment the check of a step runs in.
+type Env struct {
+	Ctx     context.Context
+	Backend txfuzz.Backend
+	Sender  common.Address
+	Tx      *types.Transaction
+	Receipt *types.Receipt
+}
+
+// Result is the result of a step. Err is nil if the step passed.
+type Result struct {
+	Step    string
+	Tx      *types.Transaction
+	Receipt *types.Receipt
+	Err     error
+}
+
+func (r *Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("FAIL %v: %v", r.Step, r.Err)
+	}
+	return fmt.Sprintf("PASS %v", r.Step)
+}
+
+var scenarios = make(map[string]*Scenario)
+
+// register adds a scenario, panicking on duplicate names.
+func register(s *Scenario) {
+	if _, ok := scenarios[s.Name]; ok {
+		panic("duplicate scenario " + s.Name)
+	}
+	scenarios[s.Name] = s
+}
+
+// Get returns the scenario with the name or nil if there is none.
+func Get(name string) *Scenario {
+	return scenarios[name]
+}
+
+// All returns all scenarios sorted by name.
+func All() []*Scenario {
+	all := make([]*Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Run sends the steps of the scenario in order from the account of key and
+// checks their outcomes. It only returns an error if the node could not be
+// queried, failed assertions are reported in the results.
+func Run(ctx context.Context, backend txfuzz.Backend, key *dilithium.Dilithium, s *Scenario) ([]*Result, error) {
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []*Result
+	for _, step := range s.Steps {
+		res, err := runStep(ctx, backend, key, chainID, step)
+		if err != nil {
+			return results, fmt.Errorf("step %v: %w", step.Name, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// runStep sends the transaction of a step and checks its outcome.
+func runStep(ctx context.Context, backend txfuzz.Backend, key *dilithium.Dilithium, chainID *big.Int, step Step) (*Result, error) {
+	sender := common.Address(key.GetAddress())
+	nonce, err := backend.PendingNonceAt(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	gasFeeCap, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, err := backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas := step.Gas
+	if gas == 0 {
+		gas = defaultGas
+	}
+	value := step.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		To:        step.To,
+		Value:     value,
+		Gas:       gas,
+		GasFeeCap: gasFeeCap,
+		GasTipCap: gasTipCap,
+		Data:      step.Data,
+	})
+	signedTx, err := types.SignTx(tx, types.NewShanghaiSigner(chainID), key)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Step: step.Name, Tx: signedTx}
+	if err := backend.SendTransaction(ctx, signedTx); err != nil {
+		if step.Outcome != Rejected && step.Outcome != Any {
+			res.Err = fmt.Errorf("expected %v, transaction rejected: %w", step.Outcome, err)
+		}
+		return res, nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, receiptTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(waitCtx, backend, signedTx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			res.Err = fmt.Errorf("transaction %v not included: %w", signedTx.Hash(), err)
+			return res, nil
+		}
+		return nil, err
+	}
+	res.Receipt = receipt
+	switch {
+	case step.Outcome == Rejected:
+		res.Err = fmt.Errorf("expected rejection, transaction included with status %v", receipt.Status)
+	case step.Outcome == Success && receipt.Status != types.ReceiptStatusSuccessful:
+		res.Err = fmt.Errorf("expected success, transaction failed using %v gas", receipt.GasUsed)
+	case step.Outcome == Failure && receipt.Status != types.ReceiptStatusFailed:
+		res.Err = fmt.Errorf("expected failure, transaction succeeded using %v gas", receipt.GasUsed)
+	case step.Check != nil:
+		env := &Env{Ctx: ctx, Backend: backend, Sender: sender, Tx: signedTx, Receipt: receipt}
+		res.Err = step.Check(env)
+	}
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package scenario
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+func newSimulatedChain(t *testing.T) (*txfuzz.SimulatedBackend, *dilithium.Dilithium) {
+	t.Helper()
+	key, err := dilithium.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc := core.GenesisAlloc{
+		key.GetAddress(): {Balance: new(big.Int).Lsh(big.NewInt(1), 128)},
+	}
+	return txfuzz.NewSimulatedBackend(alloc, params.MaxGasLimit), key
+}
+
+// TestScenarios runs all scenarios against the simulated chain, which implements
+// the forks correctly.
+func TestScenarios(t *testing.T) {
+	for _, s := range All() {
+		t.Run(s.Name, func(t *testing.T) {
+			backend, key := newSimulatedChain(t)
+			results, err := Run(context.Background(), backend, key, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(s.Steps) {
+				t.Fatalf("wrong number of results: have %d want %d", len(results), len(s.Steps))
+			}
+			for _, res := range results {
+				if res.Err != nil {
+					t.Error(res)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOutcomes(t *testing.T) {
+	backend, key := newSimulatedChain(t)
+	s := &Scenario{Steps: []Step{
+		// STOP
+		{Name: "stop", Data: []byte{0x00}, Outcome: Failure},
+		// INVALID
+		{Name: "invalid", Data: []byte{0xfe}, Outcome: Success},
+		{Name: "intrinsic gas too low", Gas: params.TxGas, Outcome: Success},
+		{Name: "included", Outcome: Rejected},
+		// PUSH1 1, PUSH0, SSTORE
+		{Name: "wrong prevrandao", Data: []byte{0x60, 0x01, 0x5f, 0x55}, Outcome: Success, Check: checkPrevRandao},
+	}}
+	results, err := Run(context.Background(), backend, key, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Err == nil {
+			t.Errorf("step %v: expected a failure", res.Step)
+		}
+	}
+	if results[3].Receipt == nil || results[3].Receipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("missing receipt of included transaction")
+	}
+}
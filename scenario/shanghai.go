@@ -0,0 +1,186 @@
+package scenario
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/vm"
+	"github.com/theQRL/go-zond/params"
+)
+
+// createGas is the gas limit of transactions executing CREATE or CREATE2.
+const createGas = 500_000
+
+func init() {
+	register(&Scenario{
+		Name:        "shanghai",
+		Description: "Warm coinbase (EIP-3651), PUSH0 (EIP-3855) and the initcode limit (EIP-3860)",
+		Steps:       append(warmCoinbaseSteps(), initcodeSteps()...),
+	})
+}
+
+// warmCoinbaseSteps access the coinbase in all possible ways from initcode.
+func warmCoinbaseSteps() []Step {
+	code := func(ops ...vm.OpCode) []byte {
+		b := make([]byte, len(ops))
+		for i, op := range ops {
+			b[i] = byte(op)
+		}
+		return b
+	}
+	push0s := func(n int) []vm.OpCode {
+		ops := make([]vm.OpCode, n)
+		for i := range ops {
+			ops[i] = vm.PUSH0
+		}
+		return ops
+	}
+	return []Step{
+		{Name: "store coinbase", Data: code(vm.COINBASE, vm.COINBASE, vm.SSTORE), Outcome: Success},
+		{Name: "call coinbase", Data: code(append(push0s(5), vm.COINBASE, vm.GAS, vm.CALL)...), Outcome: Success},
+		// CALLCODE does not exist in the zvm
+		{Name: "callcode coinbase", Data: code(append(push0s(5), vm.COINBASE, vm.GAS, 0xf2)...), Outcome: Failure},
+		{Name: "delegatecall coinbase", Data: code(append(push0s(4), vm.COINBASE, vm.GAS, vm.DELEGATECALL)...), Outcome: Success},
+		{Name: "staticcall coinbase", Data: code(append(push0s(4), vm.COINBASE, vm.GAS, vm.STATICCALL)...), Outcome: Success},
+		// SELFDESTRUCT does not exist in the zvm
+		{Name: "selfdestruct to coinbase", Data: code(vm.COINBASE, 0xff), Outcome: Failure},
+		{Name: "extcodesize coinbase", Data: code(vm.COINBASE, vm.EXTCODESIZE), Outcome: Success},
+		{Name: "extcodecopy coinbase", Data: code(append(push0s(3), vm.COINBASE, vm.EXTCODECOPY)...), Outcome: Success},
+		{Name: "extcodehash coinbase", Data: code(vm.COINBASE, vm.EXTCODEHASH), Outcome: Success},
+		{Name: "balance coinbase", Data: code(vm.COINBASE, vm.BALANCE), Outcome: Success},
+		// Loops until it runs out of gas
+		{Name: "push0 loop", Data: code(vm.JUMPDEST, vm.PUSH0, vm.JUMP), Outcome: Failure},
+	}
+}
+
+// initcodeSteps send initcode of sizes around the initcode limit, both in creation
+// transactions and to CREATE and CREATE2.
+func initcodeSteps() []Step {
+	sizes := []int{
+		params.MaxInitCodeSize - 2,
+		params.MaxInitCodeSize - 1,
+		params.MaxInitCodeSize,
+		params.MaxInitCodeSize + 1,
+		params.MaxInitCodeSize + 2,
+		params.MaxInitCodeSize * 2,
+	}
+	// Creation transactions above the limit are invalid and rejected by the pool
+	expect := func(size int, below, above Outcome) Outcome {
+		if size > params.MaxInitCodeSize {
+			return above
+		}
+		return below
+	}
+	var steps []Step
+	// size x JUMPDEST STOP
+	for _, size := range sizes {
+		initcode := append(repeatOpcode(size-1, byte(vm.JUMPDEST)), byte(vm.STOP))
+		steps = append(steps, Step{
+			Name:    fmt.Sprintf("%d jumpdests", size),
+			Data:    initcode,
+			Gas:     intrinsicGas(initcode) + uint64(size),
+			Outcome: expect(size, Success, Rejected),
+		})
+	}
+	// size x STOP
+	for _, size := range sizes {
+		initcode := repeatOpcode(size, byte(vm.STOP))
+		steps = append(steps, Step{
+			Name:    fmt.Sprintf("%d stops", size),
+			Data:    initcode,
+			Gas:     intrinsicGas(initcode),
+			Outcome: expect(size, Success, Rejected),
+			Check:   checkGasUsed(intrinsicGas(initcode)),
+		})
+	}
+	// The intrinsic gas includes 2 gas per word of initcode
+	for _, size := range []int{params.MaxInitCodeSize - 31, params.MaxInitCodeSize} {
+		initcode := repeatOpcode(size, byte(vm.STOP))
+		steps = append(steps, Step{
+			Name:    fmt.Sprintf("%d stops without initcode word gas", size),
+			Data:    initcode,
+			Gas:     intrinsicGas(initcode) - 1,
+			Outcome: Rejected,
+		})
+	}
+	// Creating from memory above the limit runs out of gas and consumes all of it
+	for _, create2 := range []bool{false, true} {
+		for _, size := range sizes {
+			name := fmt.Sprintf("create %d", size)
+			if create2 {
+				name = fmt.Sprintf("create2 %d", size)
+			}
+			steps = append(steps, Step{
+				Name:    name,
+				Data:    createFromMemory(size, create2),
+				Gas:     createGas,
+				Outcome: expect(size, Success, Failure),
+				Check:   expectCheck(size > params.MaxInitCodeSize, checkGasUsed(createGas)),
+			})
+		}
+	}
+	return steps
+}
+
+// createFromMemory returns code that runs CREATE or CREATE2 with size bytes of
+// empty memory as initcode.
+// [PUSH0 (salt)], PUSH4 size, PUSH0 (offset), PUSH0 (value), CREATE/CREATE2, POP
+func createFromMemory(size int, create2 bool) []byte {
+	var code []byte
+	if create2 {
+		code = append(code, byte(vm.PUSH0))
+	}
+	code = append(code, pushSize(size)...)
+	code = append(code, byte(vm.PUSH0), byte(vm.PUSH0))
+	if create2 {
+		code = append(code, byte(vm.CREATE2))
+	} else {
+		code = append(code, byte(vm.CREATE))
+	}
+	return append(code, byte(vm.POP))
+}
+
+// intrinsicGas returns the intrinsic gas of a creation transaction with the initcode.
+func intrinsicGas(initcode []byte) uint64 {
+	gas, err := core.IntrinsicGas(initcode, nil, true)
+	if err != nil {
+		panic(err)
+	}
+	return gas
+}
+
+// checkGasUsed asserts that the transaction used exactly the gas.
+func checkGasUsed(gas uint64) func(env *Env) error {
+	return func(env *Env) error {
+		if env.Receipt.GasUsed != gas {
+			return fmt.Errorf("wrong gas used: have %v want %v", env.Receipt.GasUsed, gas)
+		}
+		return nil
+	}
+}
+
+// expectCheck returns the check if cond is true and nil otherwise.
+func expectCheck(cond bool, check func(env *Env) error) func(env *Env) error {
+	if cond {
+		return check
+	}
+	return nil
+}
+
+// PUSH4 size
+func pushSize(size int) []byte {
+	code := []byte{byte(vm.PUSH4)}
+	sizeArr := make([]byte, 4)
+	binary.BigEndian.PutUint32(sizeArr, uint32(size))
+	code = append(code, sizeArr...)
+	return code
+}
+
+func repeatOpcode(size int, opcode byte) []byte {
+	initcode := []byte{}
+	for i := 0; i < size; i++ {
+		initcode = append(initcode, opcode)
+	}
+	return initcode
+}
@@ -0,0 +1,48 @@
+package spammer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/hexutil"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+// alTraceConfig configures the struct logger to emit the stack, which holds the
+// accessed addresses and storage keys, and the memory, whose expansion is part of
+// the cost of calls.
+var alTraceConfig = map[string]interface{}{
+	"disableStorage": true,
+	"enableMemory":   true,
+}
+
+// CheckAccessListGas traces an included transaction of sender via debug_traceTransaction
+// and verifies the gas charged for its access list.
+func CheckAccessListGas(config *Config, sender common.Address, tx *types.Transaction, receipt *types.Receipt) ([]*txfuzz.AccessListDivergence, error) {
+	if config.rpc == nil {
+		return nil, ErrNoRPC
+	}
+	var trace logger.ExecutionResult
+	if err := config.rpc.CallContext(context.Background(), &trace, "debug_traceTransaction", tx.Hash(), alTraceConfig); err != nil {
+		return nil, err
+	}
+	header, err := config.backend.HeaderByNumber(context.Background(), receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return txfuzz.CheckAccessListGas(tx, sender, header.Coinbase, receipt, &trace), nil
+}
+
+// reportDivergence prints the divergence together with the encoded transaction,
+// which can be passed to the reduce command.
+func reportDivergence(d *txfuzz.AccessListDivergence) {
+	raw, err := d.Tx.MarshalBinary()
+	if err != nil {
+		fmt.Printf("Access list gas divergence: %v\n", d)
+		return
+	}
+	fmt.Printf("Access list gas divergence: %v\ntransaction: %v\n", d, hexutil.Encode(raw))
+}
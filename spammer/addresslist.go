@@ -10,7 +10,6 @@ import (
 	"github.com/theQRL/go-zond/accounts/abi/bind"
 	"github.com/theQRL/go-zond/common"
 	"github.com/theQRL/go-zond/core/types"
-	"github.com/theQRL/go-zond/zondclient"
 )
 
 var (
@@ -134,7 +133,7 @@ func CreateAddresses(N int) ([]string, []string) {
 }
 
 func Airdrop(config *Config, value *big.Int) error {
-	backend := zondclient.NewClient(config.backend)
+	backend := config.backend
 	sender := config.faucetAcc.GetAddress()
 	var tx *types.Transaction
 	chainid, err := backend.ChainID(context.Background())
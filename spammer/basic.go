@@ -2,23 +2,28 @@ package spammer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/theQRL/FuzzyVM/filler"
 	"github.com/theQRL/go-qrllib/dilithium"
 	"github.com/theQRL/go-zond/accounts/abi/bind"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/core/txpool"
+	"github.com/theQRL/go-zond/core/txpool/legacypool"
 	"github.com/theQRL/go-zond/core/types"
 	"github.com/theQRL/go-zond/log"
-	"github.com/theQRL/go-zond/zondclient"
 	txfuzz "github.com/theQRL/tx-fuzz"
 )
 
 const TX_TIMEOUT = 5 * time.Minute
 
 func SendBasicTransactions(config *Config, d *dilithium.Dilithium, f *filler.Filler) error {
-	backend := zondclient.NewClient(config.backend)
+	backend := config.backend
 	sender := d.GetAddress()
 	chainID, err := backend.ChainID(context.Background())
 	if err != nil {
@@ -26,13 +31,13 @@ func SendBasicTransactions(config *Config, d *dilithium.Dilithium, f *filler.Fil
 		chainID = big.NewInt(0x01000666)
 	}
 
-	var lastTx *types.Transaction
+	var sentTxs []*types.Transaction
 	for i := uint64(0); i < config.N; i++ {
 		nonce, err := backend.NonceAt(context.Background(), sender, big.NewInt(-1))
 		if err != nil {
 			return err
 		}
-		tx, err := txfuzz.RandomValidTx(config.backend, f, sender, nonce, nil, nil, nil, config.accessList)
+		tx, err := txfuzz.RandomValidTx(config.backend, f, sender, nonce, nil, nil, nil, config.accessList, config.txOptions(sender))
 		if err != nil {
 			log.Warn("Could not create valid tx: %v", nonce)
 			return err
@@ -42,18 +47,116 @@ func SendBasicTransactions(config *Config, d *dilithium.Dilithium, f *filler.Fil
 			return err
 		}
 		if err := backend.SendTransaction(context.Background(), signedTx); err != nil {
-			log.Warn("Could not submit transaction: %v", err)
-			return err
+			if config.estimator != nil {
+				config.estimator.Discard(sender, signedTx)
+			}
+			if !isPoolRejection(err) {
+				log.Warn("Could not submit transaction", "err", err)
+				return err
+			}
+			// Gas limits around the boundaries are expected to be rejected by the pool
+			log.Warn("Transaction rejected by the pool", "err", err)
+			observeOutcome(config, sender, errorOutcome(err))
+			continue
 		}
-		lastTx = signedTx
+		sentTxs = append(sentTxs, signedTx)
 		time.Sleep(10 * time.Millisecond)
 	}
-	if lastTx != nil {
+	if len(sentTxs) != 0 {
 		ctx, cancel := context.WithTimeout(context.Background(), TX_TIMEOUT)
 		defer cancel()
-		if _, err := bind.WaitMined(ctx, backend, lastTx); err != nil {
+		if _, err := bind.WaitMined(ctx, backend, sentTxs[len(sentTxs)-1]); err != nil {
 			fmt.Printf("Waiting for transactions to be mined failed: %v\n", err.Error())
 		}
 	}
+	observeReceipts(config, backend, sender, sentTxs)
 	return nil
 }
+
+// poolRejections are the errors of the pool refusing a well-formed transaction,
+// e.g. for a gas limit below the intrinsic gas or above the block gas limit.
+var poolRejections = []error{
+	core.ErrIntrinsicGas,
+	core.ErrInsufficientFunds,
+	core.ErrMaxInitCodeSizeExceeded,
+	core.ErrNonceTooLow,
+	core.ErrTipAboveFeeCap,
+	txpool.ErrAlreadyKnown,
+	txpool.ErrUnderpriced,
+	txpool.ErrReplaceUnderpriced,
+	txpool.ErrAccountLimitExceeded,
+	txpool.ErrGasLimit,
+	txpool.ErrOversizedData,
+	legacypool.ErrTxPoolOverflow,
+}
+
+// isPoolRejection returns whether the error is the pool refusing the transaction
+// rather than the node failing. Errors of rpc providers only carry the message.
+func isPoolRejection(err error) bool {
+	for _, rejection := range poolRejections {
+		if errors.Is(err, rejection) || strings.Contains(err.Error(), rejection.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// observeReceipts feeds the receipts of the included transactions to the
+// contract tracker, the gas estimator, the access list checks, the coverage
+// and the corpus.
+func observeReceipts(config *Config, backend txfuzz.Backend, sender common.Address, txs []*types.Transaction) {
+	for _, tx := range txs {
+		receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			if config.estimator != nil {
+				config.estimator.Discard(sender, tx)
+			}
+			continue
+		}
+		if config.contracts != nil {
+			config.contracts.Observe(receipt)
+		}
+		if config.estimator != nil {
+			config.estimator.Observe(sender, tx, receipt)
+		}
+		if config.alChecker != nil {
+			if expected, ok := config.alChecker.Observe(sender, tx, receipt); !ok {
+				log.Warn("Access list gas mismatch", "hash", tx.Hash(), "gasUsed", receipt.GasUsed, "expected", expected)
+				observeOutcome(config, sender, "accesslist:mismatch")
+			}
+		}
+		if config.alOracle && len(tx.AccessList()) != 0 {
+			divergences, err := CheckAccessListGas(config, sender, tx, receipt)
+			if err != nil {
+				log.Warn("Could not check access list gas", "hash", tx.Hash(), "err", err)
+			}
+			for _, d := range divergences {
+				reportDivergence(d)
+				observeOutcome(config, sender, "accesslist:"+d.Reason)
+			}
+		}
+		if config.corpus != nil {
+			observeOutcome(config, sender, receiptOutcome(tx, receipt))
+			if receipt.Status == types.ReceiptStatusFailed {
+				observeOutcome(config, sender, revertOutcome(backend, sender, tx, receipt))
+			}
+		}
+		if config.coverage != nil {
+			added, err := config.coverage.TraceTransaction(config.rpc, tx.Hash())
+			if err != nil {
+				log.Warn("Could not trace transaction", "hash", tx.Hash(), "err", err)
+				continue
+			}
+			if err := config.corpus.Reward(sender, added); err != nil {
+				fmt.Printf("Could not save corpus element: %v\n", err)
+			}
+		}
+	}
+}
+
+// observeOutcome reports an outcome of a transaction of sender to the corpus.
+func observeOutcome(config *Config, sender common.Address, outcome string) {
+	if err := config.corpus.Observe(sender, outcome); err != nil {
+		fmt.Printf("Could not save corpus element: %v\n", err)
+	}
+}
@@ -4,36 +4,54 @@ import (
 	"context"
 	crand "crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"os"
 
 	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core"
+	"github.com/theQRL/go-zond/params"
 	"github.com/theQRL/go-zond/rpc"
-	"github.com/theQRL/go-zond/zondclient"
 	txfuzz "github.com/theQRL/tx-fuzz"
 	"github.com/theQRL/tx-fuzz/flags"
 	"github.com/theQRL/tx-fuzz/mutator"
 	"github.com/urfave/cli/v2"
 )
 
+// simulatedGasLimit is the block gas limit of the simulated chain.
+const simulatedGasLimit = params.MaxGasLimit
+
 type Config struct {
-	backend *rpc.Client // connection to the rpc provider
+	backend txfuzz.Backend // connection to the rpc provider or the simulated chain
+	rpc     *rpc.Client    // raw connection for the txpool and debug apis, nil for the simulated chain
+
+	N          uint64                    // number of transactions send per account
+	faucetAcc  *dilithium.Dilithium      // dilithium account of the faucet
+	accs       []*dilithium.Dilithium    // dilithium accounts
+	corpus     *Corpus                   // optional corpus to use elements from
+	accessList bool                      // whether to create accesslist transactions
+	gasLimit   uint64                    // gas limit per transaction
+	estimator  *txfuzz.GasEstimator      // optional gas estimator
+	contracts  *txfuzz.ContractTracker   // contracts deployed by our transactions
+	alChecker  *txfuzz.AccessListChecker // checks the gas used with mutated access lists
+	alOracle   bool                      // whether to verify the access list gas accounting via tracing
+	coverage   *Coverage                 // optional coverage of traced transactions
+	dict       [][]byte                  // optional dictionary of the mutators
 
-	N          uint64                 // number of transactions send per account
-	faucetAcc  *dilithium.Dilithium   // dilithium account of the faucet
-	accs       []*dilithium.Dilithium // dilithium accounts
-	corpus     [][]byte               // optional corpus to use elements from
-	accessList bool                   // whether to create accesslist transactions
-	gasLimit   uint64                 // gas limit per transaction
+	elasticity         uint64 // EIP-1559 elasticity multiplier of the chain
+	baseFeeDenominator uint64 // EIP-1559 base fee change denominator of the chain
 
-	seed int64            // seed used for generating randomness
-	mut  *mutator.Mutator // Mutator based on the seed
+	seed int64                               // seed used for generating randomness
+	mut  *mutator.Mutator                    // Mutator based on the seed
+	muts map[common.Address]*mutator.Mutator // forks of mut for the accounts of the current round
 }
 
 func NewDefaultConfig(rpcAddr string, N uint64, accessList bool, rng *rand.Rand) (*Config, error) {
 	// Setup RPC
-	backend, err := rpc.Dial(rpcAddr)
+	client, err := rpc.Dial(rpcAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -53,27 +71,49 @@ func NewDefaultConfig(rpcAddr string, N uint64, accessList bool, rng *rand.Rand)
 		return nil, err
 	}
 
+	return newConfig(txfuzz.NewRPCBackend(client), client, faucetAcc, accs, N, accessList, rng), nil
+}
+
+// NewSimulatedConfig creates a config with fresh accounts that sends transactions
+// to an in-process chain on which the faucet is funded. This allows running the
+// spammer without a node.
+func NewSimulatedConfig(accounts int, N uint64, accessList bool, rng *rand.Rand) (*Config, *txfuzz.SimulatedBackend, error) {
+	faucetAcc, err := dilithium.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	var accs []*dilithium.Dilithium
+	for i := 0; i < accounts; i++ {
+		acc, err := dilithium.New()
+		if err != nil {
+			return nil, nil, err
+		}
+		accs = append(accs, acc)
+	}
+	backend := newSimulatedBackend(faucetAcc)
+	return newConfig(backend, nil, faucetAcc, accs, N, accessList, rng), backend, nil
+}
+
+func newConfig(backend txfuzz.Backend, client *rpc.Client, faucetAcc *dilithium.Dilithium, accs []*dilithium.Dilithium, N uint64, accessList bool, rng *rand.Rand) *Config {
 	return &Config{
 		backend:    backend,
+		rpc:        client,
 		N:          N,
 		faucetAcc:  faucetAcc,
 		accs:       accs,
-		corpus:     [][]byte{},
 		accessList: accessList,
-		gasLimit:   30_000_000,
+		gasLimit:   100_000,
+		contracts:  txfuzz.NewContractTracker(),
+		alChecker:  txfuzz.NewAccessListChecker(),
 		seed:       0,
 		mut:        mutator.NewMutator(rng),
-	}, nil
-}
 
-func NewConfigFromContext(c *cli.Context) (*Config, error) {
-	// Setup RPC
-	rpcAddr := c.String(flags.RpcFlag.Name)
-	backend, err := rpc.Dial(rpcAddr)
-	if err != nil {
-		return nil, err
+		elasticity:         params.DefaultElasticityMultiplier,
+		baseFeeDenominator: params.DefaultBaseFeeChangeDenominator,
 	}
+}
 
+func NewConfigFromContext(c *cli.Context) (*Config, error) {
 	// Setup faucet
 	faucetAcc, err := dilithium.NewDilithiumFromHexSeed(txfuzz.SEED[2:])
 	if err != nil {
@@ -86,6 +126,21 @@ func NewConfigFromContext(c *cli.Context) (*Config, error) {
 		}
 	}
 
+	// Setup RPC or the simulated chain
+	var (
+		backend txfuzz.Backend
+		client  *rpc.Client
+	)
+	if c.Bool(flags.SimulatedFlag.Name) {
+		backend = newSimulatedBackend(faucetAcc)
+	} else {
+		client, err = rpc.Dial(c.String(flags.RpcFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+		backend = txfuzz.NewRPCBackend(client)
+	}
+
 	// Setup Accounts
 	var accs []*dilithium.Dilithium
 	nSeeds := c.Int(flags.CountFlag.Name)
@@ -104,6 +159,15 @@ func NewConfigFromContext(c *cli.Context) (*Config, error) {
 	// Setup gasLimit
 	gasLimit := c.Int(flags.GasLimitFlag.Name)
 
+	// Setup gas estimation
+	var estimator *txfuzz.GasEstimator
+	if multiplier := c.Float64(flags.EstimateGasFlag.Name); multiplier != 0 {
+		if multiplier < 1 {
+			return nil, fmt.Errorf("invalid gas estimation multiplier %v, must be at least 1", multiplier)
+		}
+		estimator = txfuzz.NewGasEstimator(multiplier)
+	}
+
 	// Setup N
 	N := c.Int(flags.TxCountFlag.Name)
 	if N == 0 {
@@ -124,32 +188,124 @@ func NewConfigFromContext(c *cli.Context) (*Config, error) {
 
 	// Setup Mutator
 	mut := mutator.NewMutator(rand.New(rand.NewSource(seed)))
+	var dict [][]byte
+	if path := c.String(flags.DictionaryFlag.Name); path != "" {
+		dict, err = mutator.LoadDictionary(path)
+		if err != nil {
+			return nil, err
+		}
+		mut.SetDictionary(dict)
+	}
 
 	// Setup corpus
-	var corpus [][]byte
-	if corpusFile := c.String(flags.CorpusFlag.Name); corpusFile != "" {
-		corpus, err = readCorpusElements(corpusFile)
+	var corpus *Corpus
+	if corpusDir := c.String(flags.CorpusFlag.Name); corpusDir != "" {
+		corpus, err = NewCorpus(corpusDir)
 		if err != nil {
 			return nil, err
 		}
+		// Feed new outcomes and coverage back to the mutator
+		corpus.mut = mut
+		mut.SetAdaptive(c.Bool(flags.AdaptiveFlag.Name))
+	} else if c.Bool(flags.AdaptiveFlag.Name) {
+		return nil, errors.New("adaptive scheduling needs a corpus to measure the success of the mutators")
+	}
+
+	// Setup coverage
+	var coverage *Coverage
+	if c.Bool(flags.CoverageFlag.Name) {
+		if client == nil {
+			return nil, errors.New("coverage needs the debug api of an rpc provider")
+		}
+		coverage = NewCoverage()
+	}
+
+	// Setup access list oracle
+	alOracle := c.Bool(flags.AccessListOracleFlag.Name)
+	if alOracle && client == nil {
+		return nil, errors.New("checking access lists needs the debug api of an rpc provider")
+	}
+
+	// Setup fee market parameters, the flags are only defined for the feemarket command
+	elasticity, baseFeeDenominator := uint64(params.DefaultElasticityMultiplier), uint64(params.DefaultBaseFeeChangeDenominator)
+	if c.IsSet(flags.ElasticityFlag.Name) {
+		elasticity = c.Uint64(flags.ElasticityFlag.Name)
+	}
+	if c.IsSet(flags.BaseFeeDenominatorFlag.Name) {
+		baseFeeDenominator = c.Uint64(flags.BaseFeeDenominatorFlag.Name)
+	}
+	if elasticity == 0 || baseFeeDenominator == 0 {
+		return nil, errors.New("elasticity and base fee denominator must be positive")
 	}
 
 	return &Config{
 		backend:    backend,
+		rpc:        client,
 		N:          uint64(N),
 		faucetAcc:  faucetAcc,
 		accessList: !c.Bool(flags.NoALFlag.Name),
 		gasLimit:   uint64(gasLimit),
+		estimator:  estimator,
+		contracts:  txfuzz.NewContractTracker(),
+		alChecker:  txfuzz.NewAccessListChecker(),
+		alOracle:   alOracle,
+		coverage:   coverage,
+		dict:       dict,
 		seed:       seed,
 		accs:       accs,
 		corpus:     corpus,
 		mut:        mut,
+
+		elasticity:         elasticity,
+		baseFeeDenominator: baseFeeDenominator,
 	}, nil
 }
 
-func setupN(backend *rpc.Client, keys int, gasLimit int) (int, error) {
-	client := zondclient.NewClient(backend)
-	header, err := client.HeaderByNumber(context.Background(), nil)
+// Simulated reports whether transactions are sent to the in-process simulated chain.
+func (c *Config) Simulated() bool {
+	_, ok := c.backend.(*txfuzz.SimulatedBackend)
+	return ok
+}
+
+// txOptions returns the options for generating transactions of sender. They draw
+// from the mutator of sender for the current round, if any.
+func (c *Config) txOptions(sender common.Address) *txfuzz.TxOptions {
+	mut := c.muts[sender]
+	if mut == nil {
+		mut = mutator.NewMutator(rand.New(rand.NewSource(rand.Int63())))
+		mut.SetDictionary(c.dict)
+	}
+	return &txfuzz.TxOptions{
+		GasLimit:    c.gasLimit,
+		Estimator:   c.estimator,
+		Contracts:   c.contracts,
+		Code:        func() []byte { return c.randomCode(mut) },
+		AccessLists: c.alChecker,
+		Dictionary:  c.dict,
+		Mutator:     mut,
+	}
+}
+
+// randomCode returns bytecode of the corpus or nil if there is none. Half of the
+// time the code is mutated, possibly splicing in blocks of other corpus code.
+func (c *Config) randomCode(mut *mutator.Mutator) []byte {
+	code := c.corpus.RandomCode(mut)
+	if code == nil || mut.Intn(2) == 0 {
+		return code
+	}
+	return mut.MutateCode(code, c.corpus.RandomCode(mut))
+}
+
+// newSimulatedBackend creates an in-process chain on which the faucet is funded.
+func newSimulatedBackend(faucetAcc *dilithium.Dilithium) *txfuzz.SimulatedBackend {
+	alloc := core.GenesisAlloc{
+		faucetAcc.GetAddress(): {Balance: new(big.Int).Lsh(big.NewInt(1), 128)},
+	}
+	return txfuzz.NewSimulatedBackend(alloc, simulatedGasLimit)
+}
+
+func setupN(backend txfuzz.Backend, keys int, gasLimit int) (int, error) {
+	header, err := backend.HeaderByNumber(context.Background(), nil)
 	if err != nil {
 		return 0, err
 	}
@@ -168,6 +324,9 @@ func readCorpusElements(path string) ([][]byte, error) {
 	}
 	corpus := make([][]byte, 0, len(stats))
 	for _, file := range stats {
+		if file.IsDir() {
+			continue
+		}
 		b, err := os.ReadFile(fmt.Sprintf("%v/%v", path, file.Name()))
 		if err != nil {
 			return nil, err
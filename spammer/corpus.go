@@ -0,0 +1,284 @@
+package spammer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	zond "github.com/theQRL/go-zond"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/crypto"
+	txfuzz "github.com/theQRL/tx-fuzz"
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+// codeDir is the subdirectory of the corpus holding bytecode instead of filler inputs.
+const codeDir = "code"
+
+// Corpus is a persistent set of filler inputs. Inputs whose transactions produced
+// an outcome that was not seen before are written back to the corpus directory,
+// so that the next run starts from the evolved corpus.
+// Additionally, the corpus can hold bytecode that is used directly in transactions.
+type Corpus struct {
+	dir string
+
+	mu       sync.Mutex
+	elems    []*corpusElem
+	hashes   map[common.Hash]*corpusElem
+	outcomes map[string]struct{}
+	inputs   map[common.Address][]byte   // filler input currently used by an account
+	applied  map[common.Address][]string // mutators applied to the input of an account
+	mut      *mutator.Mutator            // optional mutator rewarded for interesting inputs
+	codes    map[common.Hash][]byte
+	codeList [][]byte
+}
+
+// corpusElem is a corpus element together with its scheduling energy.
+// Elements that increased coverage have a higher energy and are picked more often.
+type corpusElem struct {
+	data   []byte
+	hash   common.Hash
+	energy uint64
+}
+
+// NewCorpus loads the corpus from dir, creating the directory if it does not exist.
+func NewCorpus(dir string) (*Corpus, error) {
+	if err := os.MkdirAll(filepath.Join(dir, codeDir), 0755); err != nil {
+		return nil, err
+	}
+	elems, err := readCorpusElements(dir)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := readCorpusElements(filepath.Join(dir, codeDir))
+	if err != nil {
+		return nil, err
+	}
+	c := &Corpus{
+		dir:      dir,
+		hashes:   make(map[common.Hash]*corpusElem),
+		outcomes: make(map[string]struct{}),
+		inputs:   make(map[common.Address][]byte),
+		applied:  make(map[common.Address][]string),
+		codes:    make(map[common.Hash][]byte),
+	}
+	for _, elem := range elems {
+		c.insert(elem)
+	}
+	for _, code := range codes {
+		c.insertCode(code)
+	}
+	return c, nil
+}
+
+// Len returns the number of elements in the corpus.
+func (c *Corpus) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.elems)
+}
+
+// Random returns a copy of a random corpus element that is safe to mutate or nil
+// if the corpus is empty. Elements are picked proportionally to their energy,
+// drawn from the source of mut.
+func (c *Corpus) Random(mut *mutator.Mutator) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.elems) == 0 {
+		return nil
+	}
+	var total uint64
+	for _, elem := range c.elems {
+		total += elem.energy
+	}
+	pick := uint64(mut.Intn(int(min(max(total, 1), math.MaxInt))))
+	for _, elem := range c.elems[:len(c.elems)-1] {
+		if pick < elem.energy {
+			return bytes.Clone(elem.data)
+		}
+		pick -= elem.energy
+	}
+	return bytes.Clone(c.elems[len(c.elems)-1].data)
+}
+
+// Elements returns copies of all filler inputs of the corpus.
+func (c *Corpus) Elements() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elems := make([][]byte, 0, len(c.elems))
+	for _, elem := range c.elems {
+		elems = append(elems, bytes.Clone(elem.data))
+	}
+	return elems
+}
+
+// Assign records the filler input an account is currently using together with
+// the mutators that produced it.
+func (c *Corpus) Assign(account common.Address, input []byte, applied []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inputs[account] = append([]byte{}, input...)
+	c.applied[account] = applied
+}
+
+// reward credits the mutators that produced the input of account once.
+func (c *Corpus) reward(account common.Address) {
+	if c.mut != nil {
+		c.mut.Reward(c.applied[account])
+	}
+	delete(c.applied, account)
+}
+
+// Observe records an outcome of a transaction sent by account. If the outcome
+// was not seen before, the input of the account is added to the corpus.
+func (c *Corpus) Observe(account common.Address, outcome string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.outcomes[outcome]; ok {
+		return nil
+	}
+	c.outcomes[outcome] = struct{}{}
+	input, ok := c.inputs[account]
+	if !ok {
+		return nil
+	}
+	c.reward(account)
+	return c.add(input)
+}
+
+// Reward increases the energy of the input of account by the amount of new coverage
+// it produced, adding the input to the corpus if needed.
+func (c *Corpus) Reward(account common.Address, coverage int) error {
+	if c == nil || coverage <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	input, ok := c.inputs[account]
+	if !ok {
+		return nil
+	}
+	c.reward(account)
+	if err := c.add(input); err != nil {
+		return err
+	}
+	c.hashes[crypto.Keccak256Hash(input)].energy += uint64(coverage)
+	return nil
+}
+
+// Add adds an element to the corpus and writes it to the corpus directory.
+func (c *Corpus) Add(elem []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.add(elem)
+}
+
+func (c *Corpus) add(data []byte) error {
+	elem, ok := c.insert(data)
+	if !ok {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(c.dir, elem.hash.Hex()), data, 0644)
+}
+
+// insert adds the data to the in-memory corpus, returning false if it was already present.
+func (c *Corpus) insert(data []byte) (*corpusElem, bool) {
+	hash := crypto.Keccak256Hash(data)
+	if elem, ok := c.hashes[hash]; ok {
+		return elem, false
+	}
+	elem := &corpusElem{data: data, hash: hash, energy: 1}
+	c.hashes[hash] = elem
+	c.elems = append(c.elems, elem)
+	return elem, true
+}
+
+// AddCode adds bytecode to the corpus and writes it to the code directory.
+func (c *Corpus) AddCode(code []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, ok := c.insertCode(code)
+	if !ok {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(c.dir, codeDir, hash.Hex()), code, 0644)
+}
+
+func (c *Corpus) insertCode(code []byte) (common.Hash, bool) {
+	hash := crypto.Keccak256Hash(code)
+	if _, ok := c.codes[hash]; ok {
+		return hash, false
+	}
+	c.codes[hash] = code
+	c.codeList = append(c.codeList, code)
+	return hash, true
+}
+
+// RandomCode returns a copy of random bytecode of the corpus drawn from the source
+// of mut or nil if there is none.
+func (c *Corpus) RandomCode(mut *mutator.Mutator) []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.codeList) == 0 {
+		return nil
+	}
+	return bytes.Clone(c.codeList[mut.Intn(len(c.codeList))])
+}
+
+// errorOutcome classifies an error returned by the node.
+// Everything after the first colon is dropped, as it usually contains values.
+func errorOutcome(err error) string {
+	msg, _, _ := strings.Cut(err.Error(), ":")
+	return "error:" + msg
+}
+
+// receiptOutcome classifies the receipt of a transaction by its status and gas pattern.
+func receiptOutcome(tx *types.Transaction, receipt *types.Receipt) string {
+	return fmt.Sprintf("status:%v created:%v logs:%v gas:%v allgas:%v",
+		receipt.Status,
+		receipt.ContractAddress != (common.Address{}),
+		min(len(receipt.Logs), 8),
+		bits.Len64(receipt.GasUsed),
+		receipt.GasUsed == tx.Gas(),
+	)
+}
+
+// revertOutcome replays a failed transaction on the state of the parent block
+// to retrieve its revert reason.
+func revertOutcome(backend txfuzz.Backend, sender common.Address, tx *types.Transaction, receipt *types.Receipt) string {
+	msg := zond.CallMsg{
+		From:       sender,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		GasFeeCap:  tx.GasFeeCap(),
+		GasTipCap:  tx.GasTipCap(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, common.Big1)
+	if _, err := backend.CallContract(context.Background(), msg, parent); err != nil {
+		return "revert:" + err.Error()
+	}
+	return "revert:none"
+}
@@ -0,0 +1,150 @@
+package spammer
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+func TestCorpusAdd(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range [][]byte{{1}, {2}, {1}, {2, 3}} {
+		if err := c.Add(elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, code := range [][]byte{{0x60}, {0x60}} {
+		if err := c.AddCode(code); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Len() != 3 {
+		t.Fatalf("duplicate elements added: have %d want 3", c.Len())
+	}
+	// Reloading the directory restores the elements and the code
+	c, err = NewCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 3 {
+		t.Fatalf("elements not persisted: have %d want 3", c.Len())
+	}
+	for _, want := range [][]byte{{1}, {2}, {2, 3}} {
+		found := false
+		for _, elem := range c.Elements() {
+			found = found || bytes.Equal(elem, want)
+		}
+		if !found {
+			t.Errorf("element %x missing", want)
+		}
+	}
+	if code := c.RandomCode(mutator.NewMutator(rand.New(rand.NewSource(1)))); !bytes.Equal(code, []byte{0x60}) {
+		t.Errorf("wrong code: %x", code)
+	}
+	files, err := os.ReadDir(filepath.Join(dir, codeDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("duplicate code written: %d files", len(files))
+	}
+}
+
+func TestCorpusObserve(t *testing.T) {
+	c, err := NewCorpus(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := common.Address{1}
+	tests := []struct {
+		input   []byte
+		outcome string
+		len     int
+	}{
+		{[]byte{1}, "status:1", 1},
+		// Known outcomes don't add the input
+		{[]byte{2}, "status:1", 1},
+		{[]byte{2}, "status:0", 2},
+		// Known inputs are not added twice
+		{[]byte{2}, "error:nonce too low", 2},
+	}
+	for i, test := range tests {
+		c.Assign(account, test.input, nil)
+		if err := c.Observe(account, test.outcome); err != nil {
+			t.Fatal(err)
+		}
+		if c.Len() != test.len {
+			t.Errorf("test %d: have %d elements want %d", i, c.Len(), test.len)
+		}
+	}
+	// Unknown accounts don't add anything
+	if err := c.Observe(common.Address{2}, "status:2"); err != nil || c.Len() != 2 {
+		t.Errorf("input of unknown account added: %v %d", err, c.Len())
+	}
+}
+
+func TestCorpusRandom(t *testing.T) {
+	c, err := NewCorpus(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mut := mutator.NewMutator(rand.New(rand.NewSource(1)))
+	if elem := c.Random(mut); elem != nil {
+		t.Fatalf("empty corpus returned %x", elem)
+	}
+	account := common.Address{1}
+	c.Add([]byte{1})
+	c.Assign(account, []byte{2}, nil)
+	// The element with new coverage is added with an energy of 1 + 99
+	if err := c.Reward(account, 99); err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[byte]int)
+	for i := 0; i < 10_000; i++ {
+		elem := c.Random(mut)
+		counts[elem[0]]++
+		// The element is a copy
+		elem[0] = 0xff
+	}
+	if counts[0xff] != 0 {
+		t.Fatalf("random returned a corpus element instead of a copy")
+	}
+	if counts[1] == 0 || counts[1] > 300 || counts[2] < 9_700 {
+		t.Errorf("elements not picked by energy: %v", counts)
+	}
+	// The same seed picks the same elements
+	a, b := mutator.NewMutator(rand.New(rand.NewSource(2))), mutator.NewMutator(rand.New(rand.NewSource(2)))
+	for i := 0; i < 100; i++ {
+		if x, y := c.Random(a), c.Random(b); !bytes.Equal(x, y) {
+			t.Fatalf("pick %d differs: %x != %x", i, x, y)
+		}
+	}
+}
+
+func TestCorpusRandomZeroEnergy(t *testing.T) {
+	c, err := NewCorpus(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add([]byte{1})
+	c.Add([]byte{2})
+	for _, elem := range c.elems {
+		elem.energy = 0
+	}
+	// Without energy, the last element is picked instead of panicking
+	mut := mutator.NewMutator(rand.New(rand.NewSource(1)))
+	for i := 0; i < 100; i++ {
+		if elem := c.Random(mut); !bytes.Equal(elem, []byte{2}) {
+			t.Fatalf("wrong element: %x", elem)
+		}
+	}
+}
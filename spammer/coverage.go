@@ -0,0 +1,97 @@
+package spammer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/rpc"
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+)
+
+// traceConfig configures the struct logger to only emit what is needed for coverage.
+var traceConfig = map[string]interface{}{
+	"disableStack":   true,
+	"disableStorage": true,
+}
+
+// edge is a transition between two instructions of the same call frame.
+type edge struct {
+	from uint64
+	to   uint64
+	op   string
+}
+
+// Coverage accumulates opcode and (pc, opcode) edge coverage of traced transactions.
+type Coverage struct {
+	mu    sync.Mutex
+	ops   map[string]struct{}
+	edges map[edge]struct{}
+}
+
+// NewCoverage creates a new, empty coverage map.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		ops:   make(map[string]struct{}),
+		edges: make(map[edge]struct{}),
+	}
+}
+
+// TraceTransaction traces an included transaction via debug_traceTransaction
+// and returns the number of newly covered opcodes and edges.
+func (c *Coverage) TraceTransaction(backend *rpc.Client, hash common.Hash) (int, error) {
+	var res logger.ExecutionResult
+	if err := backend.CallContext(context.Background(), &res, "debug_traceTransaction", hash, traceConfig); err != nil {
+		return 0, err
+	}
+	return c.Add(res.StructLogs), nil
+}
+
+// Add adds the coverage of the struct logs and returns the number of newly covered opcodes and edges.
+func (c *Coverage) Add(logs []logger.StructLogRes) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		added int
+		// last pc per call depth, so that calls don't create bogus edges
+		last  = make(map[int]uint64)
+		depth = 0
+	)
+	for _, log := range logs {
+		if log.Depth > depth {
+			// A new call frame starts at pc 0
+			delete(last, log.Depth)
+		}
+		depth = log.Depth
+		if _, ok := c.ops[log.Op]; !ok {
+			c.ops[log.Op] = struct{}{}
+			added++
+		}
+		e := edge{from: last[log.Depth], to: log.Pc, op: log.Op}
+		if _, ok := c.edges[e]; !ok {
+			c.edges[e] = struct{}{}
+			added++
+		}
+		last[log.Depth] = log.Pc
+	}
+	return added
+}
+
+// Ops returns the number of covered opcodes.
+func (c *Coverage) Ops() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.ops)
+}
+
+// Edges returns the number of covered edges.
+func (c *Coverage) Edges() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.edges)
+}
+
+func (c *Coverage) String() string {
+	return fmt.Sprintf("coverage: opcodes: %v edges: %v", c.Ops(), c.Edges())
+}
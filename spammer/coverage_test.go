@@ -0,0 +1,47 @@
+package spammer
+
+import (
+	"testing"
+
+	"github.com/theQRL/go-zond/zond/tracers/logger"
+)
+
+func TestCoverageAdd(t *testing.T) {
+	// PUSH1 PUSH1 CALL into a callee running PUSH1 STOP, then ISZERO CALL into the callee again
+	logs := []logger.StructLogRes{
+		{Pc: 0, Op: "PUSH1", Depth: 1},
+		{Pc: 2, Op: "PUSH1", Depth: 1},
+		{Pc: 4, Op: "CALL", Depth: 1},
+		{Pc: 0, Op: "PUSH1", Depth: 2},
+		{Pc: 2, Op: "STOP", Depth: 2},
+		{Pc: 5, Op: "ISZERO", Depth: 1},
+		{Pc: 6, Op: "CALL", Depth: 1},
+		{Pc: 0, Op: "PUSH1", Depth: 2},
+		{Pc: 2, Op: "STOP", Depth: 2},
+	}
+	tests := []struct {
+		logs  []logger.StructLogRes
+		added int
+		ops   int
+		edges int
+	}{
+		// Both frames of the callee start at pc 0 and share their edges
+		{logs, 10, 4, 6},
+		{logs, 0, 4, 6},
+		{logs[:5], 0, 4, 6},
+		// Edges differ in their pcs and opcodes
+		{[]logger.StructLogRes{{Pc: 0, Op: "PUSH1", Depth: 1}, {Pc: 2, Op: "STOP", Depth: 1}}, 0, 4, 6},
+		{[]logger.StructLogRes{{Pc: 0, Op: "PUSH1", Depth: 1}, {Pc: 2, Op: "ISZERO", Depth: 1}}, 1, 4, 7},
+		{[]logger.StructLogRes{{Pc: 0, Op: "PUSH1", Depth: 1}, {Pc: 3, Op: "STOP", Depth: 1}}, 1, 4, 8},
+		{nil, 0, 4, 8},
+	}
+	c := NewCoverage()
+	for i, test := range tests {
+		if added := c.Add(test.logs); added != test.added {
+			t.Errorf("test %d: added %d want %d", i, added, test.added)
+		}
+		if c.Ops() != test.ops || c.Edges() != test.edges {
+			t.Errorf("test %d: have %d ops %d edges, want %d ops %d edges", i, c.Ops(), c.Edges(), test.ops, test.edges)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package spammer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/accounts/abi/bind"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/math"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+const (
+	// feeMarketPhaseLength is the number of blocks the fee market spammer fills or idles.
+	feeMarketPhaseLength = 8
+	// feeMarketWait is how long to wait for the block the probes target.
+	feeMarketWait = 1 * time.Minute
+)
+
+// ErrBaseFee is returned if the base fee or the inclusion of a transaction violates EIP-1559.
+var ErrBaseFee = errors.New("base fee mismatch")
+
+// gasBurner is code that loops until it runs out of gas.
+// JUMPDEST, PUSH0, JUMP
+var gasBurner = []byte{0x5b, 0x5f, 0x56}
+
+// baseFeeProbe is a transaction with a fee cap relative to the predicted next base fee.
+type baseFeeProbe struct {
+	name   string
+	offset int64 // offset of the fee cap to the predicted base fee
+	tx     *types.Transaction
+}
+
+// SendFeeMarketTransactions alternates between filling blocks above the gas target to drive
+// the base fee up and idling to let it fall. In both phases it sends probe transactions with
+// fee caps at, just above and just below the predicted next base fee and verifies their
+// inclusion against the base fee of the block headers.
+func SendFeeMarketTransactions(config *Config, d *dilithium.Dilithium, f *filler.Filler) error {
+	client := config.backend
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return err
+	}
+	parent, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if (parent.Number.Uint64()/feeMarketPhaseLength)%2 == 0 {
+		if err := fillBlocks(config, client, d, chainID, parent); err != nil {
+			return err
+		}
+		// Refresh the parent as filling might have taken a while
+		if parent, err = client.HeaderByNumber(context.Background(), nil); err != nil {
+			return err
+		}
+	}
+	return probeBaseFee(config, client, d, chainID, parent)
+}
+
+// fillBlocks sends transactions that burn all of their gas. Together with the other
+// accounts, they fill the blocks until the end of the current phase.
+func fillBlocks(config *Config, client txfuzz.Backend, d *dilithium.Dilithium, chainID *big.Int, parent *types.Header) error {
+	sender := d.GetAddress()
+	// Use the latest nonce so that stuck probes get replaced
+	nonce, err := client.NonceAt(context.Background(), sender, nil)
+	if err != nil {
+		return err
+	}
+	tip, err := client.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return err
+	}
+	feeCap, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return err
+	}
+	// Overpay so the fillers get included while the base fee rises and replace stuck probes
+	tip = new(big.Int).Add(new(big.Int).Mul(tip, big.NewInt(2)), common.Big1)
+	feeCap = new(big.Int).Add(new(big.Int).Mul(feeCap, big.NewInt(2)), tip)
+	// Split the share of this account of the remaining blocks into few enough
+	// transactions that all of them are executable in the pool
+	blocks := feeMarketPhaseLength - parent.Number.Uint64()%feeMarketPhaseLength
+	budget := blocks * parent.GasLimit / uint64(max(len(config.accs), 1))
+	gas := min(max(config.gasLimit, budget/poolAccountSlots), parent.GasLimit)
+	fillers := (budget + gas - 1) / gas
+	// Send no more fillers than the account can pay for next to the probes
+	balance, err := client.BalanceAt(context.Background(), sender, nil)
+	if err != nil {
+		return err
+	}
+	reserve := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(3*params.TxGas))
+	affordable := new(big.Int).Sub(balance, reserve)
+	if affordable.Sign() > 0 {
+		affordable.Div(affordable, new(big.Int).Mul(feeCap, new(big.Int).SetUint64(gas)))
+		if affordable.IsUint64() {
+			fillers = min(fillers, affordable.Uint64())
+		}
+	} else {
+		fillers = 0
+	}
+	if fillers == 0 {
+		fmt.Printf("Skipping fillers, %v can not afford them\n", common.Address(sender))
+		return nil
+	}
+	for i := uint64(0); i < fillers; i++ {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce + i,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			Value:     common.Big0,
+			Data:      gasBurner,
+		})
+		signedTx, err := types.SignTx(tx, types.NewShanghaiSigner(chainID), d)
+		if err != nil {
+			return err
+		}
+		if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+			// Fillers of the previous round are still pending at the same price
+			if isRejection(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// probeBaseFee sends transactions with fee caps around the base fee predicted from parent,
+// waits for the next block and checks the base fee and the inclusion of the probes.
+func probeBaseFee(config *Config, client txfuzz.Backend, d *dilithium.Dilithium, chainID *big.Int, parent *types.Header) error {
+	predicted := calcBaseFee(parent, config.elasticity, config.baseFeeDenominator)
+	target := new(big.Int).Add(parent.Number, common.Big1)
+	nonce, err := client.PendingNonceAt(context.Background(), d.GetAddress())
+	if err != nil {
+		return err
+	}
+	latestNonce, err := client.NonceAt(context.Background(), d.GetAddress(), nil)
+	if err != nil {
+		return err
+	}
+	// Without pending transactions of the account, the probes are executable right away
+	executable := nonce == latestNonce
+	tip, err := client.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return err
+	}
+	// The probe below the base fee comes last so it does not block the others
+	probes := []*baseFeeProbe{
+		{name: "at", offset: 0},
+		{name: "above", offset: 1},
+		{name: "below", offset: -1},
+	}
+	to := common.Address(d.GetAddress())
+	for i, probe := range probes {
+		feeCap := new(big.Int).Add(predicted, big.NewInt(probe.offset))
+		if feeCap.Sign() < 0 {
+			feeCap = new(big.Int)
+		}
+		probeTip := tip
+		if probeTip.Cmp(feeCap) > 0 {
+			probeTip = feeCap
+		}
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce + uint64(i),
+			GasTipCap: probeTip,
+			GasFeeCap: feeCap,
+			Gas:       params.TxGas,
+			To:        &to,
+			Value:     common.Big1,
+		})
+		signedTx, err := types.SignTx(tx, types.NewShanghaiSigner(chainID), d)
+		if err != nil {
+			return err
+		}
+		// The pool may reject probes below its price limit, which is fine
+		if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+			fmt.Printf("Could not send %v base fee probe: %v\n", probe.name, err)
+			break
+		}
+		probe.tx = signedTx
+	}
+
+	header, err := waitForBlock(client, target)
+	if err != nil {
+		return err
+	}
+	if header.ParentHash == parent.Hash() && header.BaseFee.Cmp(predicted) != 0 {
+		return fmt.Errorf("%w: block %v has baseFee %v, want %v", ErrBaseFee, target, header.BaseFee, predicted)
+	}
+	// Wait a few slots for the probes at and above the base fee to be included
+	ctx, cancel := context.WithTimeout(context.Background(), feeMarketWait)
+	defer cancel()
+	for _, probe := range probes {
+		if probe.tx == nil {
+			continue
+		}
+		var receipt *types.Receipt
+		if probe.offset < 0 {
+			if receipt, err = client.TransactionReceipt(context.Background(), probe.tx.Hash()); err != nil {
+				// Not included (yet)
+				continue
+			}
+		} else if receipt, err = bind.WaitMined(ctx, client, probe.tx); err != nil {
+			if err := checkExcluded(client, d.GetAddress(), probe); err != nil {
+				return err
+			}
+			continue
+		}
+		// A block with room and a base fee the probe pays must include it. The simulated
+		// chain mines a block for every transaction.
+		room := header.GasUsed+uint64(len(probes))*params.TxGas <= header.GasLimit
+		if !config.Simulated() && executable && probe.offset >= 0 && room && receipt.BlockNumber.Cmp(target) > 0 && header.BaseFee.Cmp(probe.tx.GasFeeCap()) <= 0 {
+			return fmt.Errorf("%w: %v probe %v with feeCap %v not included in block %v with baseFee %v and gasUsed %v", ErrBaseFee, probe.name, probe.tx.Hash(), probe.tx.GasFeeCap(), target, header.BaseFee, header.GasUsed)
+		}
+		included := header
+		if receipt.BlockNumber.Cmp(target) != 0 {
+			if included, err = client.HeaderByNumber(context.Background(), receipt.BlockNumber); err != nil {
+				return err
+			}
+		}
+		if included.BaseFee.Cmp(probe.tx.GasFeeCap()) > 0 {
+			return fmt.Errorf("%w: %v probe %v with feeCap %v included in block %v with baseFee %v", ErrBaseFee, probe.name, probe.tx.Hash(), probe.tx.GasFeeCap(), receipt.BlockNumber, included.BaseFee)
+		}
+	}
+	return nil
+}
+
+// checkExcluded returns an error if probe is not included although all previous
+// transactions of sender are and its fee cap covers the base fee of the latest block.
+func checkExcluded(client txfuzz.Backend, sender common.Address, probe *baseFeeProbe) error {
+	latest, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	nonce, err := client.NonceAt(context.Background(), sender, nil)
+	if err != nil {
+		return err
+	}
+	if nonce == probe.tx.Nonce() && latest.BaseFee.Cmp(probe.tx.GasFeeCap()) <= 0 {
+		return fmt.Errorf("%w: %v probe %v with feeCap %v not included, block %v has baseFee %v", ErrBaseFee, probe.name, probe.tx.Hash(), probe.tx.GasFeeCap(), latest.Number, latest.BaseFee)
+	}
+	return nil
+}
+
+// FeeMarketAirdropValue returns the value every account needs per round of the fee
+// market spammer. Next to the value and gas of the probes, it covers the share of the
+// account of the fillers of a whole phase, which pay a multiple of the gas price.
+func FeeMarketAirdropValue(config *Config) (*big.Int, error) {
+	header, err := config.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := config.backend.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	value := new(big.Int).Mul(new(big.Int).SetUint64((1+config.N)*1_000_000), big.NewInt(params.GWei))
+	fillers := new(big.Int).SetUint64(feeMarketPhaseLength * header.GasLimit / uint64(max(len(config.accs), 1)))
+	fillers.Mul(fillers, gasPrice)
+	fillers.Mul(fillers, big.NewInt(3))
+	return value.Add(value, fillers), nil
+}
+
+// calcBaseFee returns the base fee of the child of parent according to EIP-1559 with
+// the elasticity multiplier and base fee change denominator of the target chain.
+func calcBaseFee(parent *types.Header, elasticity, denominator uint64) *big.Int {
+	target := parent.GasLimit / elasticity
+	if parent.GasUsed == target {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+	var delta *big.Int
+	if parent.GasUsed > target {
+		delta = new(big.Int).SetUint64(parent.GasUsed - target)
+	} else {
+		delta = new(big.Int).SetUint64(target - parent.GasUsed)
+	}
+	delta.Mul(delta, parent.BaseFee)
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, new(big.Int).SetUint64(denominator))
+	if parent.GasUsed > target {
+		// The base fee rises by at least 1 if the parent was above the target
+		return delta.Add(parent.BaseFee, math.BigMax(delta, common.Big1))
+	}
+	return math.BigMax(delta.Sub(parent.BaseFee, delta), common.Big0)
+}
+
+// waitForBlock polls until the block with the given number is available.
+func waitForBlock(client txfuzz.Backend, number *big.Int) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), feeMarketWait)
+	defer cancel()
+	for {
+		header, err := client.HeaderByNumber(ctx, number)
+		if err == nil {
+			return header, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for block %v: %w", number, ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
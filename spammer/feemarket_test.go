@@ -0,0 +1,78 @@
+package spammer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/theQRL/go-zond/consensus/misc/eip1559"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+)
+
+func TestCalcBaseFee(t *testing.T) {
+	const gasLimit = 30_000_000
+	for _, baseFee := range []int64{0, 1, 7, 8, 1_000_000_000} {
+		for _, gasUsed := range []uint64{0, 1, gasLimit / 4, gasLimit/2 - 1, gasLimit / 2, gasLimit/2 + 1, gasLimit} {
+			parent := &types.Header{
+				Number:   big.NewInt(1),
+				GasLimit: gasLimit,
+				GasUsed:  gasUsed,
+				BaseFee:  big.NewInt(baseFee),
+			}
+			want := eip1559.CalcBaseFee(params.TestChainConfig, parent)
+			have := calcBaseFee(parent, params.DefaultElasticityMultiplier, params.DefaultBaseFeeChangeDenominator)
+			if have.Cmp(want) != 0 {
+				t.Errorf("baseFee %v gasUsed %v: have %v want %v", baseFee, gasUsed, have, want)
+			}
+		}
+	}
+	// Other chains can change faster
+	parent := &types.Header{GasLimit: 40, GasUsed: 40, BaseFee: big.NewInt(1000)}
+	if have := calcBaseFee(parent, 4, 2); have.Cmp(big.NewInt(2500)) != 0 {
+		t.Errorf("have %v want 2500", have)
+	}
+}
+
+func TestFillBlocksBalance(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	acc := config.accs[0]
+	parent, err := config.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fee cap of the fillers at twice the gas price plus the doubled tip
+	feeCap := new(big.Int).Add(new(big.Int).Mul(node.gasPrice, big.NewInt(2)), big.NewInt(3))
+	tests := []struct {
+		gas     uint64 // gas the balance pays for at the fee cap of the fillers
+		fillers bool
+	}{
+		{0, false},
+		{3 * params.TxGas, false},
+		{45_000_000, true},
+		{1_000_000_000, true},
+	}
+	for i, tt := range tests {
+		balance := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(tt.gas))
+		node.setBalance(acc.GetAddress(), balance)
+		before := len(sentBy(t, node, acc.GetAddress()))
+		if err := fillBlocks(config, config.backend, acc, node.chainID, parent); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		txs := sentBy(t, node, acc.GetAddress())[before:]
+		if tt.fillers != (len(txs) > 0) {
+			t.Fatalf("test %d: sent %d fillers", i, len(txs))
+		}
+		if len(txs) == 0 {
+			continue
+		}
+		cost := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(3*params.TxGas))
+		for _, tx := range txs {
+			cost.Add(cost, new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas())))
+		}
+		if cost.Cmp(balance) > 0 {
+			t.Errorf("test %d: fillers and probes cost %v, balance %v", i, cost, balance)
+		}
+	}
+}
@@ -11,12 +11,12 @@ import (
 	"github.com/theQRL/go-zond/accounts/abi/bind"
 	"github.com/theQRL/go-zond/common"
 	"github.com/theQRL/go-zond/core/types"
-	"github.com/theQRL/go-zond/zondclient"
+	txfuzz "github.com/theQRL/tx-fuzz"
 )
 
 const batchSize = 50
 
-func SendTx(d *dilithium.Dilithium, backend *zondclient.Client, to common.Address, value *big.Int) (*types.Transaction, error) {
+func SendTx(d *dilithium.Dilithium, backend txfuzz.Backend, to common.Address, value *big.Int) (*types.Transaction, error) {
 	sender := d.GetAddress()
 	nonce, err := backend.NonceAt(context.Background(), sender, nil)
 	if err != nil {
@@ -25,7 +25,7 @@ func SendTx(d *dilithium.Dilithium, backend *zondclient.Client, to common.Addres
 	return sendTxWithNonce(d, backend, to, value, nonce)
 }
 
-func sendTxWithNonce(d *dilithium.Dilithium, backend *zondclient.Client, to common.Address, value *big.Int, nonce uint64) (*types.Transaction, error) {
+func sendTxWithNonce(d *dilithium.Dilithium, backend txfuzz.Backend, to common.Address, value *big.Int, nonce uint64) (*types.Transaction, error) {
 	chainid, err := backend.ChainID(context.Background())
 	if err != nil {
 		return nil, err
@@ -45,7 +45,7 @@ func sendTxWithNonce(d *dilithium.Dilithium, backend *zondclient.Client, to comm
 	return signedTx, backend.SendTransaction(context.Background(), signedTx)
 }
 
-func sendRecurringTx(d *dilithium.Dilithium, backend *zondclient.Client, to common.Address, value *big.Int, numTxs uint64) (*types.Transaction, error) {
+func sendRecurringTx(d *dilithium.Dilithium, backend txfuzz.Backend, to common.Address, value *big.Int, numTxs uint64) (*types.Transaction, error) {
 	sender := d.GetAddress()
 	nonce, err := backend.NonceAt(context.Background(), sender, nil)
 	if err != nil {
@@ -74,7 +74,7 @@ func Unstuck(config *Config) error {
 
 func tryUnstuck(config *Config, d *dilithium.Dilithium) error {
 	var (
-		client = zondclient.NewClient(config.backend)
+		client = config.backend
 		addr   = d.GetAddress()
 	)
 	for i := 0; i < 100; i++ {
@@ -106,7 +106,7 @@ func tryUnstuck(config *Config, d *dilithium.Dilithium) error {
 }
 
 func isStuck(config *Config, account common.Address) (uint64, error) {
-	client := zondclient.NewClient(config.backend)
+	client := config.backend
 	nonce, err := client.NonceAt(context.Background(), account, nil)
 	if err != nil {
 		return 0, err
@@ -0,0 +1,108 @@
+package spammer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/theQRL/go-zond/common/hexutil"
+	"github.com/theQRL/go-zond/log"
+)
+
+// stateTest is the subset of a state test as written by FuzzyVM and gozvmlab we import.
+type stateTest struct {
+	Pre map[string]struct {
+		Code hexutil.Bytes `json:"code"`
+	} `json:"pre"`
+	Transaction struct {
+		To   string          `json:"to"`
+		Data []hexutil.Bytes `json:"data"`
+	} `json:"transaction"`
+}
+
+// ImportStateTests reads all state tests in path (a file or a directory) and
+// returns the bytecode of the pre-state accounts as well as the initcode of
+// contract creations. Json files that are not state tests are skipped.
+func ImportStateTests(path string) ([][]byte, error) {
+	var codes [][]byte
+	err := walkFiles(path, func(file string, data []byte) error {
+		if filepath.Ext(file) != ".json" {
+			return nil
+		}
+		var tests map[string]stateTest
+		if err := json.Unmarshal(data, &tests); err != nil {
+			// Directories of state tests often hold other json files as well
+			log.Warn("Skipping file that is not a state test", "file", file, "err", err)
+			return nil
+		}
+		for _, test := range tests {
+			for _, acc := range test.Pre {
+				if len(acc.Code) != 0 {
+					codes = append(codes, acc.Code)
+				}
+			}
+			if test.Transaction.To == "" {
+				for _, initcode := range test.Transaction.Data {
+					if len(initcode) != 0 {
+						codes = append(codes, initcode)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// ImportPrograms reads all programs in path (a file or a directory). Programs can
+// either be raw bytecode or hex encoded bytecode, e.g. from program.Program.Hex.
+func ImportPrograms(path string) ([][]byte, error) {
+	var codes [][]byte
+	err := walkFiles(path, func(file string, data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
+		text := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(text, "0x") {
+			text = "0x" + text
+		}
+		if code, err := hexutil.Decode(text); err == nil {
+			codes = append(codes, code)
+		} else {
+			codes = append(codes, bytes.Clone(data))
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// ImportFillers reads raw filler inputs, e.g. the go-fuzz corpus of FuzzyVM.
+func ImportFillers(path string) ([][]byte, error) {
+	var fillers [][]byte
+	err := walkFiles(path, func(file string, data []byte) error {
+		if len(data) != 0 {
+			fillers = append(fillers, data)
+		}
+		return nil
+	})
+	return fillers, err
+}
+
+// walkFiles calls fn with the content of every regular file in path.
+func walkFiles(path string, fn func(file string, data []byte) error) error {
+	return filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return fn(file, data)
+	})
+}
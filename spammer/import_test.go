@@ -0,0 +1,98 @@
+package spammer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFixtures writes the files to a temporary directory and returns its path.
+func writeFixtures(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// checkCodes fails the test if codes and want differ, ignoring the order.
+func checkCodes(t *testing.T, name string, codes [][]byte, want ...[]byte) {
+	t.Helper()
+	slices.SortFunc(codes, bytes.Compare)
+	slices.SortFunc(want, bytes.Compare)
+	if !slices.EqualFunc(codes, want, bytes.Equal) {
+		t.Errorf("%v: have %x want %x", name, codes, want)
+	}
+}
+
+func TestImportStateTests(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"call.json": `{"call": {
+			"pre": {
+				"0x01": {"code": "0x6001"},
+				"0x02": {"code": "0x"}
+			},
+			"transaction": {"to": "0x01", "data": ["0x1234"]}
+		}}`,
+		"sub/create.json": `{"create": {
+			"pre": {"0x03": {"code": "0x6003"}},
+			"transaction": {"to": "", "data": ["0x6004", "0x"]}
+		}}`,
+		// Files next to the state tests
+		"sub/config.json": `["not", "a", "state", "test"]`,
+		"broken.json":     `{`,
+		"README.md":       "0x6005",
+	})
+	codes, err := ImportStateTests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCodes(t, "state tests", codes, []byte{0x60, 0x01}, []byte{0x60, 0x03}, []byte{0x60, 0x04})
+	// Single files are imported as well
+	codes, err = ImportStateTests(filepath.Join(dir, "call.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCodes(t, "single state test", codes, []byte{0x60, 0x01})
+	if _, err := ImportStateTests(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+func TestImportPrograms(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"hex":      "0x6001\n",
+		"bare":     "6002",
+		"raw":      "\x60\x03",
+		"empty":    "",
+		"sub/text": "not hex",
+	})
+	codes, err := ImportPrograms(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCodes(t, "programs", codes, []byte{0x60, 0x01}, []byte{0x60, 0x02}, []byte{0x60, 0x03}, []byte("not hex"))
+}
+
+func TestImportFillers(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"a":     "0x6001",
+		"sub/b": "\x00\x01",
+		"empty": "",
+	})
+	fillers, err := ImportFillers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Filler inputs are taken as they are
+	checkCodes(t, "fillers", fillers, []byte("0x6001"), []byte{0x00, 0x01})
+}
@@ -0,0 +1,76 @@
+package spammer
+
+import (
+	"bytes"
+	"math/bits"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	txfuzz "github.com/theQRL/tx-fuzz"
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+// minimizeTries is the number of removals tried per element.
+const minimizeTries = 256
+
+// programSignature describes the program generated from a filler input
+// by its opcode set and the magnitude of its size.
+type programSignature struct {
+	ops  [256]bool
+	size int
+}
+
+// signature generates the program of the filler input and returns its signature.
+func signature(input []byte) programSignature {
+	code := txfuzz.RandomCode(filler.NewFiller(input))
+	sig := programSignature{size: bits.Len(uint(len(code)))}
+	for pc := 0; pc < len(code); pc++ {
+		op := code[pc]
+		sig.ops[op] = true
+		// Skip the immediates of PUSH1..PUSH32
+		if op >= 0x60 && op <= 0x7f {
+			pc += int(op - 0x5f)
+		}
+	}
+	return sig
+}
+
+// MinimizeCorpus buckets the elements by the signature of their generated program
+// and returns the smallest element of every bucket.
+func MinimizeCorpus(elems [][]byte) [][]byte {
+	var (
+		buckets = make(map[programSignature][]byte)
+		order   []programSignature
+	)
+	for _, elem := range elems {
+		sig := signature(elem)
+		best, ok := buckets[sig]
+		if !ok {
+			order = append(order, sig)
+		}
+		if !ok || len(elem) < len(best) || (len(elem) == len(best) && bytes.Compare(elem, best) < 0) {
+			buckets[sig] = elem
+		}
+	}
+	res := make([][]byte, 0, len(order))
+	for _, sig := range order {
+		res = append(res, buckets[sig])
+	}
+	return res
+}
+
+// MinimizeElement shrinks the element by removing random chunks of bytes
+// as long as the signature of the generated program stays the same.
+func MinimizeElement(mut *mutator.Mutator, elem []byte) []byte {
+	want := signature(elem)
+	best := bytes.Clone(elem)
+	for i := 0; i < minimizeTries; i++ {
+		candidate := mut.RemoveBytes(bytes.Clone(best))
+		if candidate == nil {
+			break
+		}
+		if signature(candidate) == want {
+			best = candidate
+		}
+	}
+	return best
+}
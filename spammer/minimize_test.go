@@ -0,0 +1,51 @@
+package spammer
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/theQRL/tx-fuzz/mutator"
+)
+
+func TestMinimizeCorpus(t *testing.T) {
+	// The generator ignores the second byte after 0x11, but not after 0x88
+	var (
+		a, a1, a2 = []byte{0x11}, []byte{0x11, 1}, []byte{0x11, 2}
+		b, b2     = []byte{0x88}, []byte{0x88, 2}
+	)
+	if signature(a) != signature(a1) || signature(a) != signature(a2) || signature(b) == signature(b2) || signature(a) == signature(b) {
+		t.Fatal("unexpected signatures of the fixtures")
+	}
+	tests := []struct {
+		elems [][]byte
+		want  [][]byte
+	}{
+		{nil, [][]byte{}},
+		{[][]byte{a}, [][]byte{a}},
+		// The shortest element of a bucket is kept
+		{[][]byte{a2, a1, a}, [][]byte{a}},
+		{[][]byte{a, a1, a}, [][]byte{a}},
+		// Elements of the same length are compared bytewise
+		{[][]byte{a2, a1}, [][]byte{a1}},
+		// Buckets are returned in the order they were first seen
+		{[][]byte{b, a1, b2, a}, [][]byte{b, a, b2}},
+		{[][]byte{b2, b, a2}, [][]byte{b2, b, a2}},
+	}
+	for i, test := range tests {
+		if have := MinimizeCorpus(test.elems); !slices.EqualFunc(have, test.want, bytes.Equal) {
+			t.Errorf("test %d: have %x want %x", i, have, test.want)
+		}
+	}
+}
+
+func TestMinimizeElement(t *testing.T) {
+	elem := make([]byte, 256)
+	rand.New(rand.NewSource(1)).Read(elem)
+	mut := mutator.NewMutator(rand.New(rand.NewSource(1)))
+	minimized := MinimizeElement(mut, elem)
+	if len(minimized) > len(elem) || signature(minimized) != signature(elem) {
+		t.Fatalf("minimized element of %d bytes changed the program", len(minimized))
+	}
+}
@@ -0,0 +1,379 @@
+package spammer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/accounts/abi/bind"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/math"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+const (
+	// poolAccountSlots is the default number of executable transaction slots guaranteed per account.
+	poolAccountSlots = 16
+	// poolPriceBump is the default minimum price bump percentage to replace a transaction.
+	poolPriceBump = 10
+	// minReplacementTip is the lowest tip replacements start from. Below it, prices just
+	// under and above the price bump threshold round to the same value.
+	minReplacementTip = 100
+	// evictionFillers is the number of transactions filling a whole block each that are
+	// sent to raise the base fee.
+	evictionFillers = 2
+	// evictionWait is how long to wait for the fillers to be included.
+	evictionWait = 2 * time.Minute
+)
+
+// ErrPoolState is returned if the txpool did not reach the state expected by a scenario.
+var ErrPoolState = errors.New("unexpected txpool state")
+
+type poolScenario func(p *poolSpammer) error
+
+var poolScenarios = []poolScenario{
+	poolNonceGap,
+	poolReplacementChain,
+	poolCapacity,
+	poolBaseFeeEviction,
+}
+
+// poolSpammer holds the state shared by the txpool scenarios of a single account.
+type poolSpammer struct {
+	config  *Config
+	client  txfuzz.Backend
+	acc     *dilithium.Dilithium
+	addr    common.Address
+	f       *filler.Filler
+	chainID *big.Int
+}
+
+// SendTxpoolTransactions stresses the transaction pool with a scenario chosen by the filler
+// and verifies via the txpool RPCs that the pool reaches the expected state.
+func SendTxpoolTransactions(config *Config, d *dilithium.Dilithium, f *filler.Filler) error {
+	client := config.backend
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return err
+	}
+	p := &poolSpammer{
+		config:  config,
+		client:  client,
+		acc:     d,
+		addr:    d.GetAddress(),
+		f:       f,
+		chainID: chainID,
+	}
+	for i := uint64(0); i < config.N; i++ {
+		scenario := poolScenarios[int(f.Byte())%len(poolScenarios)]
+		if err := scenario(p); err != nil {
+			fmt.Printf("Txpool scenario failed for %v: %v\n", p.addr, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// poolNonceGap sends a run of transactions with a nonce gap, checks that they are queued,
+// then fills the gap and checks that all of them are promoted to pending.
+func poolNonceGap(p *poolSpammer) error {
+	nonce, tip, feeCap, err := p.defaults()
+	if err != nil {
+		return err
+	}
+	n := uint64(p.f.Byte()%8) + 1
+	var gapped []uint64
+	for i := uint64(1); i <= n; i++ {
+		if _, err := p.send(nonce+i, tip, feeCap); err != nil {
+			return err
+		}
+		gapped = append(gapped, nonce+i)
+	}
+	if err := p.expect(nil, gapped); err != nil {
+		return err
+	}
+	if _, err := p.send(nonce, tip, feeCap); err != nil {
+		return err
+	}
+	return p.expect(append([]uint64{nonce}, gapped...), nil)
+}
+
+// poolReplacementChain replaces a transaction several times with increasing tips,
+// checks that an insufficient bump is rejected and that the last replacement is kept.
+func poolReplacementChain(p *poolSpammer) error {
+	nonce, tip, feeCap, err := p.defaults()
+	if err != nil {
+		return err
+	}
+	// Small prices can't be bumped by less than the price bump, so start from a
+	// tip at which prices just below and above the threshold differ
+	tip = math.BigMax(tip, big.NewInt(minReplacementTip))
+	feeCap = math.BigMax(feeCap, tip)
+	last, err := p.send(nonce, tip, feeCap)
+	if err != nil {
+		return err
+	}
+	replacements := int(p.f.Byte()%5) + 1
+	for i := 0; i < replacements; i++ {
+		// An increase below the price bump must not replace the transaction
+		lowTip := new(big.Int).Sub(replacementThreshold(tip), common.Big1)
+		lowFeeCap := new(big.Int).Sub(replacementThreshold(feeCap), common.Big1)
+		if _, err := p.send(nonce, lowTip, lowFeeCap); err == nil {
+			return fmt.Errorf("%w: replacement below the %v%% price bump accepted", ErrPoolState, poolPriceBump)
+		} else if !isRejection(err) {
+			return err
+		}
+		percent := poolPriceBump + 1 + int(p.f.Byte()%50)
+		tip, feeCap = bump(tip, percent), bump(feeCap, percent)
+		if last, err = p.send(nonce, tip, feeCap); err != nil {
+			if strings.Contains(err.Error(), "nonce too low") {
+				// The previous replacement was already included
+				return nil
+			}
+			return err
+		}
+	}
+	acc, err := inspectAccount(p.config, p.addr, nil)
+	if err != nil {
+		return err
+	}
+	for _, tx := range append(acc.Pending, acc.Queued...) {
+		if tx.Nonce == nonce && tx.Hash != last.Hash() {
+			return fmt.Errorf("%w: nonce %v held %v, want replacement %v", ErrPoolState, nonce, tx.Hash, last.Hash())
+		}
+	}
+	return nil
+}
+
+// poolCapacity sends more consecutive transactions than an account has guaranteed slots
+// and checks that all of them end up executable without gaps.
+func poolCapacity(p *poolSpammer) error {
+	nonce, tip, feeCap, err := p.defaults()
+	if err != nil {
+		return err
+	}
+	n := uint64(poolAccountSlots + int(p.f.Byte()%poolAccountSlots))
+	var nonces []uint64
+	for i := uint64(0); i < n; i++ {
+		if _, err := p.send(nonce+i, tip, feeCap); err != nil {
+			// The pool is allowed to reject transactions once it is full
+			if strings.Contains(err.Error(), "txpool is full") {
+				break
+			}
+			return err
+		}
+		nonces = append(nonces, nonce+i)
+	}
+	return p.expect(nonces, nil)
+}
+
+// poolBaseFeeEviction sends a transaction with a fee cap at the current base fee behind
+// transactions that fill whole blocks. Filling the blocks raises the base fee above the
+// fee cap, so the transaction must not be included anymore.
+func poolBaseFeeEviction(p *poolSpammer) error {
+	nonce, tip, feeCap, err := p.defaults()
+	if err != nil {
+		return err
+	}
+	header, err := p.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	// Overpay so the fillers get included while the base fee rises
+	fillerTip := new(big.Int).Add(new(big.Int).Mul(tip, big.NewInt(2)), common.Big1)
+	fillerFeeCap := new(big.Int).Add(new(big.Int).Mul(feeCap, big.NewInt(2)), fillerTip)
+	gas, err := p.evictionGas(header, fillerFeeCap)
+	if err != nil {
+		return err
+	}
+	if gas <= header.GasLimit/p.config.elasticity {
+		// Fillers below the gas target don't raise the base fee
+		fmt.Printf("Skipping base fee eviction, %v can not afford to fill blocks\n", p.addr)
+		return nil
+	}
+	var lastFiller *types.Transaction
+	for i := uint64(0); i < evictionFillers; i++ {
+		if lastFiller, err = p.sendData(nonce+i, fillerTip, fillerFeeCap, gas, nil, gasBurner); err != nil {
+			return err
+		}
+	}
+	probeFeeCap := new(big.Int).Set(header.BaseFee)
+	probeTip := math.BigMin(tip, probeFeeCap)
+	probe, err := p.send(nonce+evictionFillers, probeTip, probeFeeCap)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), evictionWait)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, p.client, lastFiller)
+	if err != nil {
+		return fmt.Errorf("waiting for fillers: %w", err)
+	}
+	// The block after the fillers is the first one the probe fits into
+	next, err := waitForBlock(p.client, new(big.Int).Add(receipt.BlockNumber, common.Big1))
+	if err != nil {
+		return err
+	}
+	if next.BaseFee.Cmp(probeFeeCap) <= 0 {
+		return fmt.Errorf("filling blocks did not raise the base fee %v above %v", next.BaseFee, probeFeeCap)
+	}
+	if receipt, err := p.client.TransactionReceipt(context.Background(), probe.Hash()); err == nil {
+		included, err := p.client.HeaderByNumber(context.Background(), receipt.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if included.BaseFee.Cmp(probeFeeCap) > 0 {
+			return fmt.Errorf("%w: tx %v with feeCap %v included in block %v with baseFee %v", ErrPoolState, probe.Hash(), probeFeeCap, receipt.BlockNumber, included.BaseFee)
+		}
+		return nil
+	}
+	// Replace the probe, so that it doesn't block the following transactions
+	unblockTip := replacementThreshold(probeTip)
+	unblockFeeCap := math.BigMax(replacementThreshold(probeFeeCap), new(big.Int).Add(new(big.Int).Mul(next.BaseFee, big.NewInt(2)), unblockTip))
+	if _, err := p.send(nonce+evictionFillers, unblockTip, unblockFeeCap); err != nil && !isRejection(err) {
+		return err
+	}
+	return nil
+}
+
+// evictionGas returns the gas of the fillers of poolBaseFeeEviction. They stay below the
+// block gas limit, which may decrease until they are included, and within what the
+// account can pay for at the given fee cap next to the probe and its replacement.
+func (p *poolSpammer) evictionGas(header *types.Header, feeCap *big.Int) (uint64, error) {
+	gas := header.GasLimit - evictionFillers*(header.GasLimit/params.GasLimitBoundDivisor)
+	balance, err := p.client.BalanceAt(context.Background(), p.addr, nil)
+	if err != nil {
+		return 0, err
+	}
+	reserve := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(3*params.TxGas))
+	if balance.Cmp(reserve) <= 0 {
+		return 0, nil
+	}
+	affordable := new(big.Int).Sub(balance, reserve)
+	affordable.Div(affordable, new(big.Int).Mul(feeCap, big.NewInt(evictionFillers)))
+	if affordable.IsUint64() {
+		gas = min(gas, affordable.Uint64())
+	}
+	return gas, nil
+}
+
+// TxpoolAirdropValue returns the value every account needs per round of the txpool
+// spammer. Next to the value and gas of the other scenarios, it covers the fillers
+// of the base fee eviction, which pay a multiple of the gas price for whole blocks.
+func TxpoolAirdropValue(config *Config) (*big.Int, error) {
+	header, err := config.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := config.backend.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	value := new(big.Int).Mul(new(big.Int).SetUint64((1+config.N)*1_000_000), big.NewInt(params.GWei))
+	fillers := new(big.Int).SetUint64(evictionFillers * header.GasLimit)
+	fillers.Mul(fillers, gasPrice)
+	fillers.Mul(fillers, big.NewInt(3))
+	return value.Add(value, fillers), nil
+}
+
+// defaults returns the next pending nonce and the suggested caps.
+func (p *poolSpammer) defaults() (uint64, *big.Int, *big.Int, error) {
+	nonce, err := p.client.PendingNonceAt(context.Background(), p.addr)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	tip, err := p.client.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	feeCap, err := p.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return nonce, tip, feeCap, nil
+}
+
+// send signs and sends a self-transfer with the given nonce and caps.
+func (p *poolSpammer) send(nonce uint64, tip, feeCap *big.Int) (*types.Transaction, error) {
+	return p.sendData(nonce, tip, feeCap, params.TxGas, &p.addr, nil)
+}
+
+// sendData signs and sends a transaction with the given nonce, caps, gas and data.
+func (p *poolSpammer) sendData(nonce uint64, tip, feeCap *big.Int, gas uint64, to *common.Address, data []byte) (*types.Transaction, error) {
+	value := big.NewInt(1)
+	if to == nil {
+		value = new(big.Int)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   p.chainID,
+		Nonce:     nonce,
+		To:        to,
+		Value:     value,
+		Gas:       gas,
+		GasFeeCap: feeCap,
+		GasTipCap: tip,
+		Data:      data,
+	})
+	signedTx, err := types.SignTx(tx, types.NewShanghaiSigner(p.chainID), p.acc)
+	if err != nil {
+		return nil, err
+	}
+	return signedTx, p.client.SendTransaction(context.Background(), signedTx)
+}
+
+// expect checks that the given nonces are pending respectively queued.
+// Nonces below the account nonce were already included and are skipped.
+func (p *poolSpammer) expect(pending, queued []uint64) error {
+	acc, err := inspectAccount(p.config, p.addr, nil)
+	if err != nil {
+		return err
+	}
+	check := func(kind string, nonces []uint64, txs []PoolTx) error {
+		have := make(map[uint64]struct{}, len(txs))
+		for _, tx := range txs {
+			have[tx.Nonce] = struct{}{}
+		}
+		for _, nonce := range nonces {
+			if nonce < acc.Nonce {
+				continue
+			}
+			if _, ok := have[nonce]; !ok {
+				return fmt.Errorf("%w: nonce %v of %v not %v", ErrPoolState, nonce, p.addr, kind)
+			}
+		}
+		return nil
+	}
+	if err := check("pending", pending, acc.Pending); err != nil {
+		return err
+	}
+	return check("queued", queued, acc.Queued)
+}
+
+// bump increases the value by the given percentage, rounding down.
+func bump(value *big.Int, percent int) *big.Int {
+	res := new(big.Int).Mul(value, big.NewInt(int64(100+percent)))
+	return res.Div(res, big.NewInt(100))
+}
+
+// replacementThreshold returns the lowest price the pool accepts to replace a transaction
+// with the given price, computed like the pool does.
+func replacementThreshold(price *big.Int) *big.Int {
+	return bump(price, poolPriceBump)
+}
+
+// isRejection returns whether the error is the pool refusing a transaction it already has a better version of.
+func isRejection(err error) bool {
+	for _, msg := range []string{"underpriced", "already known", "nonce too low"} {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package spammer
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	"github.com/theQRL/go-zond/params"
+)
+
+// newPoolSpammer returns a pool spammer for the first account of the config whose
+// scenarios read their parameters from input.
+func newPoolSpammer(config *Config, node *mockNode, input ...byte) *poolSpammer {
+	acc := config.accs[0]
+	return &poolSpammer{
+		config:  config,
+		client:  config.backend,
+		acc:     acc,
+		addr:    acc.GetAddress(),
+		f:       filler.NewFiller(append(input, make([]byte, 64)...)),
+		chainID: node.chainID,
+	}
+}
+
+func TestPoolNonceGap(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	// 3 transactions behind the gap
+	p := newPoolSpammer(config, node, 2)
+	if err := poolNonceGap(p); err != nil {
+		t.Fatal(err)
+	}
+	if txs := sentBy(t, node, p.addr); len(txs) != 4 || txs[3].Nonce() != 0 {
+		t.Fatalf("expected 3 gapped transactions and the one filling the gap, got %d", len(txs))
+	}
+	if latest := node.nonce(p.addr, false); latest != 4 {
+		t.Fatalf("gapped transactions not promoted: nonce %v", latest)
+	}
+}
+
+func TestPoolNonceGapError(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	node.fail("txpool_contentFrom", errors.New("boom"))
+	if err := poolNonceGap(newPoolSpammer(config, node, 2)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestPoolReplacementChain(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	// 3 replacements bumping the prices by 11%, 20% and 60%
+	p := newPoolSpammer(config, node, 2, 0, 9, 49)
+	node.setStuck(p.addr, true)
+	if err := poolReplacementChain(p); err != nil {
+		t.Fatal(err)
+	}
+	// The replacements below the price bump are rejected
+	txs := sentBy(t, node, p.addr)
+	if len(txs) != 4 {
+		t.Fatalf("wrong number of accepted transactions: have %d want 4", len(txs))
+	}
+	last := node.pooled(p.addr, 0)
+	if last == nil || last.Hash() != txs[3].Hash() {
+		t.Fatalf("pool does not hold the last replacement")
+	}
+	if last.GasTipCap().Cmp(big.NewInt(minReplacementTip)) <= 0 || last.GasFeeCap().Cmp(last.GasTipCap()) < 0 {
+		t.Errorf("unexpected prices of the last replacement: tip %v feeCap %v", last.GasTipCap(), last.GasFeeCap())
+	}
+}
+
+func TestPoolReplacementChainUnderpriced(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	p := newPoolSpammer(config, node, 0)
+	node.setStuck(p.addr, true)
+	// A pool replacing transactions without a price bump violates the pool rules
+	node.priceBump = 0
+	if err := poolReplacementChain(p); !errors.Is(err, ErrPoolState) {
+		t.Fatalf("expected %v, got %v", ErrPoolState, err)
+	}
+}
+
+func TestPoolBaseFeeEvictionUnaffordable(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	p := newPoolSpammer(config, node)
+	if err := poolBaseFeeEviction(p); err != nil {
+		t.Fatal(err)
+	}
+	if txs := sentBy(t, node, p.addr); len(txs) != 0 {
+		t.Fatalf("sent %d transactions without the balance to fill blocks", len(txs))
+	}
+}
+
+func TestPoolBaseFeeEvictionFillers(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	p := newPoolSpammer(config, node)
+	// Enough for fillers of 20M gas next to the probe and its replacement
+	feeCap := new(big.Int).Add(new(big.Int).Mul(node.gasPrice, big.NewInt(2)), big.NewInt(3))
+	balance := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(evictionFillers*20_000_000+3*params.TxGas))
+	node.setBalance(p.addr, balance)
+	// The base fee of the mock node never changes
+	if err := poolBaseFeeEviction(p); err == nil || !strings.Contains(err.Error(), "did not raise the base fee") {
+		t.Fatalf("expected the static base fee to be detected, got %v", err)
+	}
+	txs := sentBy(t, node, p.addr)
+	if len(txs) != evictionFillers+1 {
+		t.Fatalf("wrong number of transactions: have %d want %d", len(txs), evictionFillers+1)
+	}
+	for _, tx := range txs[:evictionFillers] {
+		if tx.Gas() != 20_000_000 {
+			t.Errorf("filler gas not limited by the balance: %v", tx.Gas())
+		}
+		if tx.GasFeeCap().Cmp(feeCap) != 0 {
+			t.Errorf("wrong filler fee cap: have %v want %v", tx.GasFeeCap(), feeCap)
+		}
+	}
+	// Without a balance limit, the fillers leave room for a decreasing gas limit
+	node.setBalance(p.addr, new(big.Int).Lsh(big.NewInt(1), 128))
+	poolBaseFeeEviction(p)
+	txs = sentBy(t, node, p.addr)
+	if gas := txs[len(txs)-2].Gas(); gas >= node.gasLimit || gas <= node.gasLimit/2 {
+		t.Errorf("filler gas %v not below the block gas limit %v", gas, node.gasLimit)
+	}
+}
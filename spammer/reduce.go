@@ -0,0 +1,170 @@
+package spammer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/accounts/abi/bind"
+	"github.com/theQRL/go-zond/core/txpool"
+	"github.com/theQRL/go-zond/core/types"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+const (
+	// reduceTimeout is how long the oracles wait for a transaction to be included.
+	reduceTimeout = 1 * time.Minute
+	// crashTimeout is how long the crash oracle waits for a transaction to be included,
+	// a few slots.
+	crashTimeout = 36 * time.Second
+)
+
+// CrashOracle returns an oracle that sends the transaction from the faucet and reports
+// whether the node stopped responding afterwards. The restart command is executed via
+// the shell before every attempt to start from a fresh node, as a crashed node would
+// make every later attempt look like a crash.
+func CrashOracle(config *Config, restart string) txfuzz.Oracle {
+	return func(tx *types.DynamicFeeTx) (bool, error) {
+		if err := restartNode(restart); err != nil {
+			return false, err
+		}
+		client := config.backend
+		if !isAlive(client) {
+			return false, errors.New("node is not responding before sending the transaction")
+		}
+		signedTx, err := signReduced(client, config.faucetAcc, tx)
+		if err != nil {
+			return false, err
+		}
+		if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+			// The node rejecting the transaction does not reproduce the crash
+			return !isAlive(client), nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), crashTimeout)
+		defer cancel()
+		if _, err := bind.WaitMined(ctx, client, signedTx); err != nil {
+			// Not included within a few slots, which reproduces the crash if the node died
+			return !isAlive(client), nil
+		}
+		// The node may crash after including the transaction as well
+		return !isAlive(client), nil
+	}
+}
+
+// DivergenceOracle returns an oracle that sends the transaction to the node of the
+// config as well as to other and reports whether the receipts differ. If restart
+// is set, it is executed via the shell before every attempt.
+func DivergenceOracle(config *Config, other txfuzz.Backend, restart string) txfuzz.Oracle {
+	return func(tx *types.DynamicFeeTx) (bool, error) {
+		if err := restartNode(restart); err != nil {
+			return false, err
+		}
+		// Both nodes need to execute the very same transaction
+		signedTx, err := signReduced(config.backend, config.faucetAcc, tx)
+		if err != nil {
+			return false, err
+		}
+		nonce, err := other.PendingNonceAt(context.Background(), config.faucetAcc.GetAddress())
+		if err != nil {
+			return false, err
+		}
+		if nonce != signedTx.Nonce() {
+			return false, fmt.Errorf("nodes disagree on the nonce of the faucet: %v != %v", signedTx.Nonce(), nonce)
+		}
+		a, errA := runReduced(config.backend, signedTx)
+		b, errB := runReduced(other, signedTx)
+		if errA != nil || errB != nil {
+			// Only one node rejecting the transaction is a divergence as well
+			return (errA == nil) != (errB == nil), nil
+		}
+		return receiptOutcome(a.tx, a.receipt) != receiptOutcome(b.tx, b.receipt), nil
+	}
+}
+
+type reducedResult struct {
+	tx      *types.Transaction
+	receipt *types.Receipt
+}
+
+// runReduced sends the signed transaction and waits for its receipt. A peered node
+// might already know the transaction, which is not an error.
+func runReduced(client txfuzz.Backend, signedTx *types.Transaction) (*reducedResult, error) {
+	if err := client.SendTransaction(context.Background(), signedTx); err != nil && !strings.Contains(err.Error(), txpool.ErrAlreadyKnown.Error()) {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reduceTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, client, signedTx)
+	if err != nil {
+		return nil, err
+	}
+	return &reducedResult{tx: signedTx, receipt: receipt}, nil
+}
+
+// signReduced signs the transaction with the chain id of the node and the current
+// nonce of acc.
+func signReduced(client txfuzz.Backend, acc *dilithium.Dilithium, tx *types.DynamicFeeTx) (*types.Transaction, error) {
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := client.PendingNonceAt(context.Background(), acc.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	cpy := *tx
+	cpy.ChainID = chainID
+	cpy.Nonce = nonce
+	return types.SignTx(types.NewTx(&cpy), types.NewShanghaiSigner(chainID), acc)
+}
+
+func isAlive(client txfuzz.Backend) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := client.BlockNumber(ctx)
+	return err == nil
+}
+
+func restartNode(restart string) error {
+	if restart == "" {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", restart)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restarting node failed: %w", err)
+	}
+	return nil
+}
+
+// ReduceTransaction reduces the failing transaction with the oracle.
+// It returns an error if the transaction does not reproduce the failure.
+func ReduceTransaction(tx *types.Transaction, oracle txfuzz.Oracle) (*types.DynamicFeeTx, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, fmt.Errorf("unsupported transaction type %v", tx.Type())
+	}
+	inner := &types.DynamicFeeTx{
+		ChainID:    tx.ChainId(),
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	ok, err := oracle(inner)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("transaction does not reproduce the failure")
+	}
+	return txfuzz.Reduce(inner, oracle)
+}
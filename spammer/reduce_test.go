@@ -0,0 +1,59 @@
+package spammer
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+)
+
+func TestCrashOracle(t *testing.T) {
+	to := common.Address{1}
+	tx := &types.DynamicFeeTx{
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(params.GWei),
+		Gas:       params.TxGas,
+		To:        &to,
+		Value:     common.Big1,
+	}
+	dead := errors.New("connection refused")
+	tests := []struct {
+		failing    []string // methods failing from the start
+		crashing   []string // methods failing once the transaction was sent
+		reproduced bool
+		err        bool
+	}{
+		// The transaction is included and the node keeps running
+		{nil, nil, false, false},
+		// The node includes the transaction and dies
+		{nil, []string{"zond_blockNumber"}, true, false},
+		// The node dies on receiving the transaction
+		{[]string{"zond_sendRawTransaction"}, []string{"zond_blockNumber"}, true, false},
+		// The node rejects the transaction
+		{[]string{"zond_sendRawTransaction"}, nil, false, false},
+		// The node is dead already
+		{[]string{"zond_blockNumber"}, nil, false, true},
+	}
+	for i, test := range tests {
+		node := newMockNode()
+		config := newMockConfig(t, node, 1, 1)
+		for _, method := range test.failing {
+			node.fail(method, dead)
+		}
+		node.onCall("zond_sendRawTransaction", func() {
+			for _, method := range test.crashing {
+				node.fail(method, dead)
+			}
+		})
+		reproduced, err := CrashOracle(config, "")(tx)
+		if (err != nil) != test.err {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if reproduced != test.reproduced {
+			t.Errorf("test %d: reproduced %v want %v", i, reproduced, test.reproduced)
+		}
+	}
+}
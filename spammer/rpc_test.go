@@ -0,0 +1,421 @@
+package spammer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/hexutil"
+	"github.com/theQRL/go-zond/core/types"
+	"github.com/theQRL/go-zond/params"
+	"github.com/theQRL/go-zond/rpc"
+	txfuzz "github.com/theQRL/tx-fuzz"
+)
+
+// mockNode is a fake node serving the parts of the zond and txpool namespaces used by
+// the spammer. Its behaviour can be scripted per method via fail, delay and onCall. Sent
+// transactions enter a pool and are included right away once executable, unless
+// their sender is stuck.
+type mockNode struct {
+	chainID   *big.Int
+	gasPrice  *big.Int
+	gasTipCap *big.Int
+	baseFee   *big.Int
+	gasLimit  uint64
+	priceBump int64 // percentage by which replacements have to raise both caps
+
+	mu       sync.Mutex
+	block    uint64
+	nonces   map[common.Address]uint64 // nonce of the latest block
+	pending  map[common.Address]uint64 // minimum pending nonce, see setNonces
+	balances map[common.Address]*big.Int
+	pool     map[common.Address]map[uint64]*types.Transaction // transactions not included yet
+	stuck    map[common.Address]bool
+	receipts map[common.Hash]*types.Receipt
+	txs      []*types.Transaction
+	errs     map[string]error
+	delays   map[string]time.Duration
+	hooks    map[string]func()
+}
+
+func newMockNode() *mockNode {
+	return &mockNode{
+		chainID:   big.NewInt(1337),
+		gasPrice:  big.NewInt(params.GWei),
+		gasTipCap: big.NewInt(1),
+		baseFee:   big.NewInt(params.GWei / 2),
+		gasLimit:  30_000_000,
+		priceBump: poolPriceBump,
+		nonces:    make(map[common.Address]uint64),
+		pending:   make(map[common.Address]uint64),
+		balances:  make(map[common.Address]*big.Int),
+		pool:      make(map[common.Address]map[uint64]*types.Transaction),
+		stuck:     make(map[common.Address]bool),
+		receipts:  make(map[common.Hash]*types.Receipt),
+		errs:      make(map[string]error),
+		delays:    make(map[string]time.Duration),
+		hooks:     make(map[string]func()),
+	}
+}
+
+// start serves the node via http and returns a client connected to it.
+func (n *mockNode) start(t *testing.T) *rpc.Client {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("zond", &mockAPI{n}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("txpool", &mockTxpoolAPI{n}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	client, err := rpc.Dial(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		httpServer.Close()
+		server.Stop()
+	})
+	return client
+}
+
+// fail makes all calls to method return err, a nil error resets the method.
+func (n *mockNode) fail(method string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err == nil {
+		delete(n.errs, method)
+	} else {
+		n.errs[method] = err
+	}
+}
+
+// delay delays all calls to method by d.
+func (n *mockNode) delay(method string, d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.delays[method] = d
+}
+
+// setStuck marks the transactions of account as not being included. Unmarking
+// the account includes its executable transactions.
+func (n *mockNode) setStuck(account common.Address, stuck bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stuck[account] = stuck
+	n.promote(account)
+}
+
+// setNonces sets the latest nonce of account and the pending nonce it reports
+// at least, as if transactions of the account got lost.
+func (n *mockNode) setNonces(account common.Address, latest, pending uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nonces[account] = latest
+	n.pending[account] = pending
+}
+
+// setBalance sets the balance of account.
+func (n *mockNode) setBalance(account common.Address, balance *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[account] = balance
+}
+
+func (n *mockNode) nonce(account common.Address, pending bool) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if pending {
+		return n.pendingNonce(account)
+	}
+	return n.nonces[account]
+}
+
+// pendingNonce returns the nonce after the executable transactions of account,
+// the caller must hold the lock.
+func (n *mockNode) pendingNonce(account common.Address) uint64 {
+	nonce := n.nonces[account]
+	for n.pool[account][nonce] != nil {
+		nonce++
+	}
+	return max(nonce, n.pending[account])
+}
+
+// transactions returns all transactions sent to the node.
+func (n *mockNode) transactions() []*types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*types.Transaction{}, n.txs...)
+}
+
+// pooled returns the transaction of account with nonce that is not included yet.
+func (n *mockNode) pooled(account common.Address, nonce uint64) *types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.pool[account][nonce]
+}
+
+// onCall runs fn on every call to method, e.g. to make other methods fail from then on.
+func (n *mockNode) onCall(method string, fn func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.hooks[method] = fn
+}
+
+// call applies the scripted hook, delay and error of method.
+func (n *mockNode) call(method string) error {
+	n.mu.Lock()
+	hook, d, err := n.hooks[method], n.delays[method], n.errs[method]
+	n.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
+	time.Sleep(d)
+	return err
+}
+
+func (n *mockNode) send(tx *types.Transaction) error {
+	sender, err := types.Sender(types.NewShanghaiSigner(n.chainID), tx)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if tx.Nonce() < n.nonces[sender] {
+		return fmt.Errorf("nonce too low: address %v, tx: %d state: %d", sender, tx.Nonce(), n.nonces[sender])
+	}
+	if old := n.pool[sender][tx.Nonce()]; old != nil {
+		threshold := func(price *big.Int) *big.Int {
+			res := new(big.Int).Mul(price, big.NewInt(100+n.priceBump))
+			return res.Div(res, big.NewInt(100))
+		}
+		if tx.GasTipCap().Cmp(threshold(old.GasTipCap())) < 0 || tx.GasFeeCap().Cmp(threshold(old.GasFeeCap())) < 0 {
+			return errors.New("replacement transaction underpriced")
+		}
+	}
+	if n.pool[sender] == nil {
+		n.pool[sender] = make(map[uint64]*types.Transaction)
+	}
+	n.pool[sender][tx.Nonce()] = tx
+	n.txs = append(n.txs, tx)
+	n.promote(sender)
+	return nil
+}
+
+// promote includes the executable transactions of sender unless it is stuck,
+// the caller must hold the lock.
+func (n *mockNode) promote(sender common.Address) {
+	if n.stuck[sender] {
+		return
+	}
+	for tx := n.pool[sender][n.nonces[sender]]; tx != nil; tx = n.pool[sender][n.nonces[sender]] {
+		delete(n.pool[sender], tx.Nonce())
+		n.include(sender, tx)
+	}
+}
+
+// include mines tx in a new block, the caller must hold the lock.
+func (n *mockNode) include(sender common.Address, tx *types.Transaction) {
+	n.block++
+	n.nonces[sender] = max(n.nonces[sender], tx.Nonce()+1)
+	n.receipts[tx.Hash()] = &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: tx.Gas(),
+		GasUsed:           tx.Gas(),
+		Logs:              []*types.Log{},
+		TxHash:            tx.Hash(),
+		BlockNumber:       new(big.Int).SetUint64(n.block),
+	}
+}
+
+// mockAPI is the zond namespace of the mock node.
+type mockAPI struct {
+	n *mockNode
+}
+
+func (api *mockAPI) ChainId() (*hexutil.Big, error) {
+	return (*hexutil.Big)(api.n.chainID), api.n.call("zond_chainId")
+}
+
+func (api *mockAPI) GasPrice() (*hexutil.Big, error) {
+	return (*hexutil.Big)(api.n.gasPrice), api.n.call("zond_gasPrice")
+}
+
+func (api *mockAPI) MaxPriorityFeePerGas() (*hexutil.Big, error) {
+	return (*hexutil.Big)(api.n.gasTipCap), api.n.call("zond_maxPriorityFeePerGas")
+}
+
+func (api *mockAPI) BlockNumber() (hexutil.Uint64, error) {
+	if err := api.n.call("zond_blockNumber"); err != nil {
+		return 0, err
+	}
+	api.n.mu.Lock()
+	defer api.n.mu.Unlock()
+	return hexutil.Uint64(api.n.block), nil
+}
+
+func (api *mockAPI) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	if err := api.n.call("zond_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	api.n.mu.Lock()
+	defer api.n.mu.Unlock()
+	if number < 0 {
+		number = rpc.BlockNumber(api.n.block)
+	}
+	if uint64(number) > api.n.block {
+		return nil, nil
+	}
+	return &types.Header{
+		Number:   big.NewInt(number.Int64()),
+		GasLimit: api.n.gasLimit,
+		BaseFee:  new(big.Int).Set(api.n.baseFee),
+		Extra:    []byte{},
+	}, nil
+}
+
+func (api *mockAPI) GetBalance(account common.Address, block rpc.BlockNumber) (*hexutil.Big, error) {
+	if err := api.n.call("zond_getBalance"); err != nil {
+		return nil, err
+	}
+	api.n.mu.Lock()
+	defer api.n.mu.Unlock()
+	balance := new(big.Int)
+	if b := api.n.balances[account]; b != nil {
+		balance.Set(b)
+	}
+	return (*hexutil.Big)(balance), nil
+}
+
+func (api *mockAPI) GetTransactionCount(account common.Address, block rpc.BlockNumber) (hexutil.Uint64, error) {
+	if err := api.n.call("zond_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(api.n.nonce(account, block == rpc.PendingBlockNumber)), nil
+}
+
+func (api *mockAPI) SendRawTransaction(input hexutil.Bytes) (common.Hash, error) {
+	if err := api.n.call("zond_sendRawTransaction"); err != nil {
+		return common.Hash{}, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), api.n.send(tx)
+}
+
+func (api *mockAPI) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	if err := api.n.call("zond_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	api.n.mu.Lock()
+	defer api.n.mu.Unlock()
+	return api.n.receipts[hash], nil
+}
+
+type accessListResult struct {
+	AccessList *types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+func (api *mockAPI) CreateAccessList(args json.RawMessage) (*accessListResult, error) {
+	if err := api.n.call("zond_createAccessList"); err != nil {
+		return nil, err
+	}
+	return &accessListResult{AccessList: &types.AccessList{}}, nil
+}
+
+// mockTxpoolAPI is the txpool namespace of the mock node.
+type mockTxpoolAPI struct {
+	n *mockNode
+}
+
+// ContentFrom returns the executable transactions of account as pending and
+// the ones behind a nonce gap as queued.
+func (api *mockTxpoolAPI) ContentFrom(account common.Address) (map[string]map[string]*rpcPoolTx, error) {
+	if err := api.n.call("txpool_contentFrom"); err != nil {
+		return nil, err
+	}
+	api.n.mu.Lock()
+	defer api.n.mu.Unlock()
+	content := map[string]map[string]*rpcPoolTx{
+		"pending": make(map[string]*rpcPoolTx),
+		"queued":  make(map[string]*rpcPoolTx),
+	}
+	executable := api.n.nonces[account]
+	for api.n.pool[account][executable] != nil {
+		executable++
+	}
+	for nonce, tx := range api.n.pool[account] {
+		kind := "queued"
+		if nonce < executable {
+			kind = "pending"
+		}
+		content[kind][strconv.FormatUint(nonce, 10)] = &rpcPoolTx{
+			Hash:      tx.Hash(),
+			Nonce:     hexutil.Uint64(nonce),
+			To:        tx.To(),
+			Gas:       hexutil.Uint64(tx.Gas()),
+			GasFeeCap: (*hexutil.Big)(tx.GasFeeCap()),
+			GasTipCap: (*hexutil.Big)(tx.GasTipCap()),
+		}
+	}
+	return content, nil
+}
+
+// newMockConfig creates a config with fresh accounts that talks to the mock node.
+func newMockConfig(t *testing.T, node *mockNode, accounts int, N uint64) *Config {
+	t.Helper()
+	faucetAcc, err := dilithium.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accs []*dilithium.Dilithium
+	for i := 0; i < accounts; i++ {
+		acc, err := dilithium.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		accs = append(accs, acc)
+	}
+	client := node.start(t)
+	return newConfig(txfuzz.NewRPCBackend(client), client, faucetAcc, accs, N, true, rand.New(rand.NewSource(1)))
+}
+
+func TestMockNode(t *testing.T) {
+	node := newMockNode()
+	backend := txfuzz.NewRPCBackend(node.start(t))
+	chainID, err := backend.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chainID.Cmp(node.chainID) != 0 {
+		t.Fatalf("wrong chain id: have %v want %v", chainID, node.chainID)
+	}
+	node.fail("zond_chainId", errors.New("boom"))
+	if _, err := backend.ChainID(context.Background()); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected scripted error, got %v", err)
+	}
+	node.delay("zond_blockNumber", 100*time.Millisecond)
+	start := time.Now()
+	if _, err := backend.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatal("expected scripted delay")
+	}
+}
@@ -2,11 +2,12 @@ package spammer
 
 import (
 	"fmt"
-	"math/rand"
 	"sync"
 
 	"github.com/theQRL/FuzzyVM/filler"
 	"github.com/theQRL/go-qrllib/dilithium"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/tx-fuzz/mutator"
 )
 
 type Spam func(*Config, *dilithium.Dilithium, *filler.Filler) error
@@ -17,28 +18,53 @@ func SpamTransactions(config *Config, fun Spam) error {
 	errCh := make(chan error, len(config.accs))
 	var wg sync.WaitGroup
 	wg.Add(len(config.accs))
+	// Transactions are created concurrently, so every account gets its own mutator
+	config.muts = make(map[common.Address]*mutator.Mutator, len(config.accs))
+	fillers := make([]*filler.Filler, 0, len(config.accs))
 	for _, acc := range config.accs {
 		// Setup randomness uniquely per key
 		random := make([]byte, 10000)
 		config.mut.FillBytes(&random)
+		config.muts[acc.GetAddress()] = config.mut.Fork()
 
 		var f *filler.Filler
-		if len(config.corpus) != 0 {
-			elem := config.corpus[rand.Int31n(int32(len(config.corpus)))]
-			config.mut.MutateBytes(&elem)
+		if n := config.corpus.Len(); n != 0 {
+			elem := config.corpus.Random(config.mut)
+			if n > 1 && config.mut.Intn(4) == 0 {
+				// Cross over with another element
+				elem = config.mut.Splice(elem, config.corpus.Random(config.mut))
+			}
+			elem, applied := config.mut.MutateBytes(elem)
+			config.corpus.Assign(acc.GetAddress(), elem, applied)
 			f = filler.NewFiller(elem)
 		} else {
 			// Use lower entropy randomness for filler
-			config.mut.MutateBytes(&random)
+			random, applied := config.mut.MutateBytes(random)
+			config.corpus.Assign(acc.GetAddress(), random, applied)
 			f = filler.NewFiller(random)
 		}
+		fillers = append(fillers, f)
+	}
+	for i, acc := range config.accs {
 		// Start a fuzzing thread
-		go func(acc *dilithium.Dilithium, filler *filler.Filler) {
+		go func(acc *dilithium.Dilithium, f *filler.Filler) {
 			defer wg.Done()
 			errCh <- fun(config, acc, f)
-		}(acc, f)
+		}(acc, fillers[i])
 	}
 	wg.Wait()
+	if config.estimator != nil {
+		fmt.Println(config.estimator.Stats())
+	}
+	if config.alChecker != nil {
+		fmt.Println(config.alChecker.Stats())
+	}
+	if config.coverage != nil {
+		fmt.Println(config.coverage)
+	}
+	if config.corpus != nil {
+		fmt.Println(config.mut.Stats())
+	}
 	select {
 	case err := <-errCh:
 		return err
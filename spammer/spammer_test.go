@@ -0,0 +1,233 @@
+package spammer
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/theQRL/FuzzyVM/filler"
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/core/types"
+)
+
+func randomFiller(seed int64) *filler.Filler {
+	random := make([]byte, 10000)
+	rand.New(rand.NewSource(seed)).Read(random)
+	return filler.NewFiller(random)
+}
+
+// sentBy returns the transactions of the node sent by account.
+func sentBy(t *testing.T, node *mockNode, account common.Address) []*types.Transaction {
+	t.Helper()
+	var txs []*types.Transaction
+	for _, tx := range node.transactions() {
+		sender, err := types.Sender(types.NewShanghaiSigner(node.chainID), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sender == account {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+func TestAirdrop(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 3, 1)
+	value := big.NewInt(1_000_000)
+	if err := Airdrop(config, value); err != nil {
+		t.Fatal(err)
+	}
+	txs := sentBy(t, node, config.faucetAcc.GetAddress())
+	if len(txs) != len(config.accs) {
+		t.Fatalf("wrong number of airdrops: have %v want %v", len(txs), len(config.accs))
+	}
+	for i, tx := range txs {
+		if want := common.Address(config.accs[i].GetAddress()); *tx.To() != want {
+			t.Errorf("airdrop %d: wrong recipient: have %v want %v", i, tx.To(), want)
+		}
+		if tx.Value().Cmp(value) != 0 {
+			t.Errorf("airdrop %d: wrong value: have %v want %v", i, tx.Value(), value)
+		}
+		if tx.Nonce() != uint64(i) {
+			t.Errorf("airdrop %d: wrong nonce: have %v want %v", i, tx.Nonce(), i)
+		}
+	}
+}
+
+func TestAirdropErrors(t *testing.T) {
+	for _, method := range []string{"zond_chainId", "zond_getTransactionCount", "zond_sendRawTransaction"} {
+		node := newMockNode()
+		config := newMockConfig(t, node, 2, 1)
+		node.fail(method, errors.New("boom"))
+		if err := Airdrop(config, big.NewInt(1)); err == nil {
+			t.Errorf("%v: expected airdrop to fail", method)
+		}
+	}
+}
+
+func TestUnstuck(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 2, 1)
+	faucet := config.faucetAcc.GetAddress()
+	node.setNonces(faucet, 5, 8)
+	node.delay("zond_getTransactionReceipt", 50*time.Millisecond)
+	if err := Unstuck(config); err != nil {
+		t.Fatal(err)
+	}
+	if latest, pending := node.nonce(faucet, false), node.nonce(faucet, true); latest != pending {
+		t.Fatalf("account still stuck: latest %v pending %v", latest, pending)
+	}
+	txs := sentBy(t, node, faucet)
+	if len(txs) != 3 {
+		t.Fatalf("wrong number of unstuck transactions: have %v want 3", len(txs))
+	}
+	for i, tx := range txs {
+		if *tx.To() != faucet {
+			t.Errorf("tx %d: expected self transfer, got recipient %v", i, tx.To())
+		}
+		if tx.Nonce() != uint64(5+i) {
+			t.Errorf("tx %d: wrong nonce: have %v want %v", i, tx.Nonce(), 5+i)
+		}
+	}
+	// Accounts that are not stuck don't send transactions
+	for _, acc := range config.accs {
+		if txs := sentBy(t, node, acc.GetAddress()); len(txs) != 0 {
+			t.Errorf("unexpected transactions from %v", acc.GetAddress())
+		}
+	}
+}
+
+func TestUnstuckStuckSender(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	faucet := config.faucetAcc.GetAddress()
+	node.setNonces(faucet, 5, 8)
+	node.setStuck(faucet, true)
+	// The self transfers only get included once the node releases the account
+	time.AfterFunc(200*time.Millisecond, func() { node.setStuck(faucet, false) })
+	if err := Unstuck(config); err != nil {
+		t.Fatal(err)
+	}
+	if latest, pending := node.nonce(faucet, false), node.nonce(faucet, true); latest != 8 || pending != 8 {
+		t.Fatalf("account still stuck: latest %v pending %v", latest, pending)
+	}
+	if txs := sentBy(t, node, faucet); len(txs) != 3 {
+		t.Fatalf("wrong number of unstuck transactions: have %v want 3", len(txs))
+	}
+}
+
+func TestUnstuckError(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 1)
+	node.setNonces(config.faucetAcc.GetAddress(), 0, 2)
+	node.fail("zond_sendRawTransaction", errors.New("boom"))
+	if err := Unstuck(config); err == nil {
+		t.Fatal("expected unstuck to fail")
+	}
+}
+
+func TestSendBasicTransactions(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 8)
+	acc := config.accs[0]
+	if err := SendBasicTransactions(config, acc, randomFiller(1)); err != nil {
+		t.Fatal(err)
+	}
+	txs := sentBy(t, node, acc.GetAddress())
+	if len(txs) != int(config.N) {
+		t.Fatalf("wrong number of transactions: have %v want %v", len(txs), config.N)
+	}
+	for i, tx := range txs {
+		if tx.Nonce() != uint64(i) {
+			t.Errorf("tx %d: wrong nonce: have %v want %v", i, tx.Nonce(), i)
+		}
+		if tx.ChainId().Cmp(node.chainID) != 0 {
+			t.Errorf("tx %d: wrong chain id: have %v want %v", i, tx.ChainId(), node.chainID)
+		}
+	}
+}
+
+func TestSendBasicTransactionsRejected(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 4)
+	node.fail("zond_sendRawTransaction", errors.New("txpool is full"))
+	// Rejected transactions are expected and must not abort the spammer
+	if err := SendBasicTransactions(config, config.accs[0], randomFiller(1)); err != nil {
+		t.Fatal(err)
+	}
+	if txs := node.transactions(); len(txs) != 0 {
+		t.Fatalf("unexpected transactions: %v", len(txs))
+	}
+}
+
+func TestSendBasicTransactionsSendError(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 4)
+	node.fail("zond_sendRawTransaction", errors.New("connection refused"))
+	// Errors other than pool rejections must abort the spammer
+	if err := SendBasicTransactions(config, config.accs[0], randomFiller(1)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestSendBasicTransactionsNonceError(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 1, 4)
+	node.fail("zond_getTransactionCount", errors.New("boom"))
+	if err := SendBasicTransactions(config, config.accs[0], randomFiller(1)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestSpamTransactions(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 4, 3)
+	node.delay("zond_sendRawTransaction", 5*time.Millisecond)
+	if err := SpamTransactions(config, SendBasicTransactions); err != nil {
+		t.Fatal(err)
+	}
+	for _, acc := range config.accs {
+		if txs := sentBy(t, node, acc.GetAddress()); len(txs) != int(config.N) {
+			t.Errorf("account %v: wrong number of transactions: have %v want %v", acc.GetAddress(), len(txs), config.N)
+		}
+	}
+}
+
+func TestSpamTransactionsStuckSender(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 2, 3)
+	stuck := config.accs[0].GetAddress()
+	node.setStuck(stuck, true)
+	// The spam loop waits for the transactions of the stuck sender
+	time.AfterFunc(200*time.Millisecond, func() { node.setStuck(stuck, false) })
+	if err := SpamTransactions(config, SendBasicTransactions); err != nil {
+		t.Fatal(err)
+	}
+	txs := sentBy(t, node, stuck)
+	if len(txs) != int(config.N) {
+		t.Fatalf("wrong number of transactions: have %v want %v", len(txs), config.N)
+	}
+	if latest, pending := node.nonce(stuck, false), node.nonce(stuck, true); latest != pending {
+		t.Fatalf("transactions of the stuck sender not included: latest %v pending %v", latest, pending)
+	}
+	// Afterwards the account is no longer stuck, so there is nothing to unstuck
+	if err := Unstuck(config); err != nil {
+		t.Fatal(err)
+	}
+	if have := sentBy(t, node, stuck); len(have) != len(txs) {
+		t.Errorf("unexpected unstuck transactions: have %v want %v", len(have), len(txs))
+	}
+}
+
+func TestSpamTransactionsError(t *testing.T) {
+	node := newMockNode()
+	config := newMockConfig(t, node, 2, 1)
+	node.fail("zond_getTransactionCount", errors.New("boom"))
+	if err := SpamTransactions(config, SendBasicTransactions); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,203 @@
+package spammer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/theQRL/go-zond/common"
+	"github.com/theQRL/go-zond/common/hexutil"
+)
+
+// PoolTx is a transaction of one of our accounts as reported by the txpool.
+type PoolTx struct {
+	Hash        common.Hash     `json:"hash"`
+	Nonce       uint64          `json:"nonce"`
+	To          *common.Address `json:"to"`
+	Gas         uint64          `json:"gas"`
+	GasFeeCap   *big.Int        `json:"maxFeePerGas"`
+	GasTipCap   *big.Int        `json:"maxPriorityFeePerGas"`
+	Underpriced bool            `json:"underpriced"`
+}
+
+// AccountPool groups the pooled transactions of a single sender.
+type AccountPool struct {
+	Address common.Address `json:"address"`
+	Nonce   uint64         `json:"nonce"`   // nonce of the account at the latest block
+	Pending []PoolTx       `json:"pending"` // executable transactions
+	Queued  []PoolTx       `json:"queued"`  // non-executable transactions
+	Gaps    []uint64       `json:"gaps"`    // nonces missing between Nonce and the highest pooled nonce
+}
+
+// PoolReport is a snapshot of the txpool from the perspective of our accounts.
+type PoolReport struct {
+	BaseFee  *big.Int       `json:"baseFee"`
+	Pending  uint64         `json:"pending"` // total pending transactions in the pool
+	Queued   uint64         `json:"queued"`  // total queued transactions in the pool
+	Accounts []*AccountPool `json:"accounts"`
+}
+
+// rpcPoolTx is the subset of the RPC transaction returned by the txpool namespace we care about.
+type rpcPoolTx struct {
+	Hash      common.Hash     `json:"hash"`
+	Nonce     hexutil.Uint64  `json:"nonce"`
+	To        *common.Address `json:"to"`
+	Gas       hexutil.Uint64  `json:"gas"`
+	GasFeeCap *hexutil.Big    `json:"maxFeePerGas"`
+	GasTipCap *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// ErrNoRPC is returned if the txpool or debug api is used without an rpc provider.
+var ErrNoRPC = errors.New("the txpool and debug apis need an rpc provider")
+
+// PoolStatus returns the number of pending and queued transactions in the pool via txpool_status.
+func PoolStatus(config *Config) (uint64, uint64, error) {
+	if config.rpc == nil {
+		return 0, 0, ErrNoRPC
+	}
+	var status map[string]hexutil.Uint
+	if err := config.rpc.CallContext(context.Background(), &status, "txpool_status"); err != nil {
+		return 0, 0, err
+	}
+	return uint64(status["pending"]), uint64(status["queued"]), nil
+}
+
+// InspectPool queries the txpool for the transactions of the faucet and all configured accounts.
+// Accounts without any pooled transactions are omitted from the report.
+func InspectPool(config *Config) (*PoolReport, error) {
+	client := config.backend
+	header, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	pending, queued, err := PoolStatus(config)
+	if err != nil {
+		return nil, err
+	}
+	report := &PoolReport{
+		BaseFee: header.BaseFee,
+		Pending: pending,
+		Queued:  queued,
+	}
+	addrs := []common.Address{config.faucetAcc.GetAddress()}
+	for _, acc := range config.accs {
+		addrs = append(addrs, acc.GetAddress())
+	}
+	for _, addr := range addrs {
+		acc, err := inspectAccount(config, addr, header.BaseFee)
+		if err != nil {
+			return nil, err
+		}
+		if len(acc.Pending) != 0 || len(acc.Queued) != 0 {
+			report.Accounts = append(report.Accounts, acc)
+		}
+	}
+	return report, nil
+}
+
+func inspectAccount(config *Config, addr common.Address, baseFee *big.Int) (*AccountPool, error) {
+	client := config.backend
+	nonce, err := client.NonceAt(context.Background(), addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if config.rpc == nil {
+		return nil, ErrNoRPC
+	}
+	var content map[string]map[string]*rpcPoolTx
+	if err := config.rpc.CallContext(context.Background(), &content, "txpool_contentFrom", addr); err != nil {
+		return nil, err
+	}
+	acc := &AccountPool{
+		Address: addr,
+		Nonce:   nonce,
+		Pending: toPoolTxs(content["pending"], baseFee),
+		Queued:  toPoolTxs(content["queued"], baseFee),
+	}
+	acc.Gaps = nonceGaps(nonce, acc.Pending, acc.Queued)
+	return acc, nil
+}
+
+// toPoolTxs converts the nonce-keyed RPC representation into a list sorted by nonce.
+// A transaction is marked underpriced if its fee cap is below the given base fee.
+func toPoolTxs(txs map[string]*rpcPoolTx, baseFee *big.Int) []PoolTx {
+	res := make([]PoolTx, 0, len(txs))
+	for key, tx := range txs {
+		nonce, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			nonce = uint64(tx.Nonce)
+		}
+		ptx := PoolTx{
+			Hash:  tx.Hash,
+			Nonce: nonce,
+			To:    tx.To,
+			Gas:   uint64(tx.Gas),
+		}
+		if tx.GasFeeCap != nil {
+			ptx.GasFeeCap = tx.GasFeeCap.ToInt()
+		}
+		if tx.GasTipCap != nil {
+			ptx.GasTipCap = tx.GasTipCap.ToInt()
+		}
+		if baseFee != nil && ptx.GasFeeCap != nil && ptx.GasFeeCap.Cmp(baseFee) < 0 {
+			ptx.Underpriced = true
+		}
+		res = append(res, ptx)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Nonce < res[j].Nonce })
+	return res
+}
+
+// nonceGaps returns all nonces in [nonce, highest pooled nonce] that are not in the pool.
+func nonceGaps(nonce uint64, pending, queued []PoolTx) []uint64 {
+	present := make(map[uint64]struct{})
+	highest := nonce
+	for _, txs := range [][]PoolTx{pending, queued} {
+		for _, tx := range txs {
+			present[tx.Nonce] = struct{}{}
+			if tx.Nonce > highest {
+				highest = tx.Nonce
+			}
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+	var gaps []uint64
+	for n := nonce; n < highest; n++ {
+		if _, ok := present[n]; !ok {
+			gaps = append(gaps, n)
+		}
+	}
+	return gaps
+}
+
+// PrintPoolReport prints a human readable summary of the report.
+func PrintPoolReport(report *PoolReport) {
+	fmt.Printf("Pool status: pending: %v queued: %v baseFee: %v\n", report.Pending, report.Queued, report.BaseFee)
+	if len(report.Accounts) == 0 {
+		fmt.Println("No transactions of our accounts in the pool")
+		return
+	}
+	for _, acc := range report.Accounts {
+		fmt.Printf("Account %v nonce: %v pending: %v queued: %v\n", acc.Address, acc.Nonce, len(acc.Pending), len(acc.Queued))
+		if len(acc.Gaps) != 0 {
+			fmt.Printf("\tnonce gaps: %v\n", acc.Gaps)
+		}
+		printPoolTxs("pending", acc.Pending)
+		printPoolTxs("queued", acc.Queued)
+	}
+}
+
+func printPoolTxs(kind string, txs []PoolTx) {
+	for _, tx := range txs {
+		marker := ""
+		if tx.Underpriced {
+			marker = " UNDERPRICED"
+		}
+		fmt.Printf("\t%v %v: %v feeCap: %v tipCap: %v gas: %v%v\n", kind, tx.Nonce, tx.Hash, tx.GasFeeCap, tx.GasTipCap, tx.Gas, marker)
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/theQRL/go-zond/common"
 	"github.com/theQRL/go-zond/core/types"
 	"github.com/theQRL/go-zond/params"
-	"github.com/theQRL/go-zond/rpc"
-	"github.com/theQRL/go-zond/zondclient"
+	"github.com/theQRL/tx-fuzz/mutator"
 )
 
+// maxGeneratedCodeSize is the maximum size of generated code used in transactions.
+const maxGeneratedCodeSize = 128
+
 // RandomCode creates a random byte code from the passed filler.
 func RandomCode(f *filler.Filler) []byte {
 	_, code := generator.GenerateProgram(f)
@@ -26,11 +28,22 @@ func RandomTx(f *filler.Filler) (*types.Transaction, error) {
 	gasFeeCap := big.NewInt(rand.Int63())
 	gasTipCap := big.NewInt(rand.Int63())
 	chainID := big.NewInt(rand.Int63())
-	return RandomValidTx(nil, f, common.Address{}, nonce, gasFeeCap, gasTipCap, chainID, false)
+	return RandomValidTx(nil, f, common.Address{}, nonce, gasFeeCap, gasTipCap, chainID, false, nil)
+}
+
+// TxOptions configures optional behaviour of RandomValidTx.
+type TxOptions struct {
+	GasLimit    uint64             // gas limit used for transactions, 0 = default
+	Estimator   *GasEstimator      // estimates the gas limit via the backend if set
+	Contracts   *ContractTracker   // deployed contracts that transactions can call if set
+	Code        func() []byte      // source of bytecode used instead of generated code if set
+	AccessLists *AccessListChecker // checks the gas used with mutated access lists if set
+	Dictionary  [][]byte           // byte sequences the code mutator inserts
+	Mutator     *mutator.Mutator   // source of the code mutations, math/rand if nil
 }
 
 type txConf struct {
-	rpc       *rpc.Client
+	backend   Backend
 	nonce     uint64
 	sender    common.Address
 	to        *common.Address
@@ -40,59 +53,83 @@ type txConf struct {
 	gasTipCap *big.Int
 	chainID   *big.Int
 	code      []byte
+	estimator *GasEstimator
+	contracts *ContractTracker
+	alChecker *AccessListChecker
 }
 
-func initDefaultTxConf(rpc *rpc.Client, f *filler.Filler, sender common.Address, nonce uint64, gasFeeCap, gasTipCap, chainID *big.Int) *txConf {
+func initDefaultTxConf(backend Backend, f *filler.Filler, sender common.Address, nonce uint64, gasFeeCap, gasTipCap, chainID *big.Int, opts *TxOptions) *txConf {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
 	// Set fields if non-nil
-	if rpc != nil {
-		client := zondclient.NewClient(rpc)
+	if backend != nil {
 		var err error
 		if gasFeeCap == nil {
-			gasFeeCap, err = client.SuggestGasPrice(context.Background())
+			gasFeeCap, err = backend.SuggestGasPrice(context.Background())
 			if err != nil {
 				gasFeeCap = big.NewInt(1)
 			}
 		}
 		if gasTipCap == nil {
-			gasTipCap, err = client.SuggestGasTipCap(context.Background())
+			gasTipCap, err = backend.SuggestGasTipCap(context.Background())
 			if err != nil {
 				gasTipCap = big.NewInt(1)
 			}
 		}
 		if chainID == nil {
-			chainID, err = client.ChainID(context.Background())
+			chainID, err = backend.ChainID(context.Background())
 			if err != nil {
 				chainID = big.NewInt(1)
 			}
 		}
 	}
-	gas := uint64(100000)
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = defaultGasLimit
+	}
 	to := randomAddress()
 	code := RandomCode(f)
+	mut := opts.Mutator
+	if mut == nil {
+		mut = mutator.NewMutator(rand.New(rand.NewSource(rand.Int63())))
+		mut.SetDictionary(opts.Dictionary)
+	}
+	if mut.Intn(2) == 0 {
+		code = mut.MutateCode(code)
+	}
+	code = mutator.TruncateCode(code, maxGeneratedCodeSize)
 	value := big.NewInt(0)
-	if len(code) > 128 {
-		code = code[:128]
+	if opts.Code != nil && mut.Intn(2) == 0 {
+		if c := opts.Code(); c != nil {
+			code = c
+		}
 	}
 	return &txConf{
-		rpc:       rpc,
+		backend:   backend,
 		nonce:     nonce,
 		sender:    sender,
 		to:        &to,
 		value:     value,
-		gasLimit:  gas,
+		gasLimit:  gasLimit,
 		gasFeeCap: gasFeeCap,
 		gasTipCap: gasTipCap,
 		chainID:   chainID,
 		code:      code,
+		estimator: opts.Estimator,
+		contracts: opts.Contracts,
+		alChecker: opts.AccessLists,
 	}
 }
 
 // RandomValidTx creates a random valid transaction.
 // It does not mean that the transaction will succeed, but that it is well-formed.
-// If gasPrice is not set, we will try to get it from the rpc
-// If chainID is not set, we will try to get it from the rpc
-func RandomValidTx(rpc *rpc.Client, f *filler.Filler, sender common.Address, nonce uint64, gasFeeCap, gasTipCap, chainID *big.Int, al bool) (*types.Transaction, error) {
-	conf := initDefaultTxConf(rpc, f, sender, nonce, gasFeeCap, gasTipCap, chainID)
+// If gasPrice is not set, we will try to get it from the backend
+// If chainID is not set, we will try to get it from the backend
+// The gas limit of the transaction is picked around meaningful boundaries like the
+// intrinsic gas or the block gas limit, see TxOptions for further configuration.
+func RandomValidTx(backend Backend, f *filler.Filler, sender common.Address, nonce uint64, gasFeeCap, gasTipCap, chainID *big.Int, al bool, opts *TxOptions) (*types.Transaction, error) {
+	conf := initDefaultTxConf(backend, f, sender, nonce, gasFeeCap, gasTipCap, chainID, opts)
 	if al {
 		index := rand.Intn(len(alStrategies))
 		return alStrategies[index](conf)
@@ -107,29 +144,53 @@ type txCreationStrategy func(conf *txConf) (*types.Transaction, error)
 var noAlStrategies = []txCreationStrategy{
 	contractCreation1559,
 	tx1559,
+	contractCall1559,
 }
 
 var alStrategies = append(noAlStrategies, []txCreationStrategy{
 	fullAl1559ContractCreation,
 	fullAl1559Tx,
+	mutatedAl1559ContractCreation,
+	mutatedAl1559Tx,
 }...)
 
 func contractCreation1559(conf *txConf) (*types.Transaction, error) {
 	// 1559 contract creation
-	tip, feecap, err := getCaps(conf.rpc, conf.gasFeeCap)
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
 	if err != nil {
 		return nil, err
 	}
-	return new1559Tx(conf.nonce, nil, conf.gasLimit, conf.chainID, tip, feecap, conf.value, conf.code, make(types.AccessList, 0)), nil
+	gas := randomGasLimit(conf, nil, conf.code, nil)
+	return new1559Tx(conf.nonce, nil, gas, conf.chainID, tip, feecap, conf.value, conf.code, make(types.AccessList, 0)), nil
 }
 
 func tx1559(conf *txConf) (*types.Transaction, error) {
 	// 1559 transaction
-	tip, feecap, err := getCaps(conf.rpc, conf.gasFeeCap)
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
+	if err != nil {
+		return nil, err
+	}
+	gas := randomGasLimit(conf, conf.to, conf.code, nil)
+	return new1559Tx(conf.nonce, conf.to, gas, conf.chainID, tip, feecap, conf.value, conf.code, make(types.AccessList, 0)), nil
+}
+
+func contractCall1559(conf *txConf) (*types.Transaction, error) {
+	// 1559 transaction to a contract deployed by a previous transaction
+	if conf.contracts == nil {
+		return tx1559(conf)
+	}
+	to, ok := conf.contracts.Random()
+	if !ok {
+		return tx1559(conf)
+	}
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
 	if err != nil {
 		return nil, err
 	}
-	return new1559Tx(conf.nonce, conf.to, conf.gasLimit, conf.chainID, tip, feecap, conf.value, conf.code, make(types.AccessList, 0)), nil
+	// Estimate the gas with the value that is sent
+	conf.value = randomValue()
+	gas := randomGasLimit(conf, &to, conf.code, nil)
+	return new1559Tx(conf.nonce, &to, gas, conf.chainID, tip, feecap, conf.value, conf.code, make(types.AccessList, 0)), nil
 }
 
 func fullAl1559ContractCreation(conf *txConf) (*types.Transaction, error) {
@@ -142,15 +203,16 @@ func fullAl1559ContractCreation(conf *txConf) (*types.Transaction, error) {
 		GasTipCap: conf.gasTipCap,
 		Data:      conf.code,
 	})
-	al, err := CreateAccessList(conf.rpc, tx, conf.sender)
+	al, err := CreateAccessList(conf.backend, tx, conf.sender)
 	if err != nil {
 		return nil, err
 	}
-	tip, feecap, err := getCaps(conf.rpc, conf.gasFeeCap)
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
 	if err != nil {
 		return nil, err
 	}
-	return new1559Tx(conf.nonce, nil, conf.gasLimit, conf.chainID, tip, feecap, conf.value, conf.code, *al), nil
+	gas := randomGasLimit(conf, nil, conf.code, *al)
+	return new1559Tx(conf.nonce, nil, gas, conf.chainID, tip, feecap, conf.value, conf.code, *al), nil
 }
 
 func fullAl1559Tx(conf *txConf) (*types.Transaction, error) {
@@ -164,15 +226,84 @@ func fullAl1559Tx(conf *txConf) (*types.Transaction, error) {
 		GasTipCap: conf.gasTipCap,
 		Data:      conf.code,
 	})
-	al, err := CreateAccessList(conf.rpc, tx, conf.sender)
+	al, err := CreateAccessList(conf.backend, tx, conf.sender)
 	if err != nil {
 		return nil, err
 	}
-	tip, feecap, err := getCaps(conf.rpc, conf.gasFeeCap)
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
 	if err != nil {
 		return nil, err
 	}
-	return new1559Tx(conf.nonce, conf.to, conf.gasLimit, conf.chainID, tip, feecap, conf.value, conf.code, *al), nil
+	gas := randomGasLimit(conf, conf.to, conf.code, *al)
+	return new1559Tx(conf.nonce, conf.to, gas, conf.chainID, tip, feecap, conf.value, conf.code, *al), nil
+}
+
+func mutatedAl1559ContractCreation(conf *txConf) (*types.Transaction, error) {
+	// 1559 contract creation with a mutated AL
+	return mutatedAl1559(conf, nil)
+}
+
+func mutatedAl1559Tx(conf *txConf) (*types.Transaction, error) {
+	// 1559 tx with a mutated AL
+	return mutatedAl1559(conf, conf.to)
+}
+
+// mutatedAl1559 creates a transaction with the access list created by the node
+// after applying one or more mutations to it. If the transaction is sent with the
+// gas limit the list was created with, the gas used with the unmutated list is
+// recorded in the access list checker.
+func mutatedAl1559(conf *txConf, to *common.Address) (*types.Transaction, error) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     conf.nonce,
+		To:        to,
+		Value:     conf.value,
+		Gas:       conf.gasLimit,
+		GasFeeCap: conf.gasFeeCap,
+		GasTipCap: conf.gasTipCap,
+		Data:      conf.code,
+	})
+	al, gasUsed, vmErr, err := createAccessList(conf.backend, tx, conf.sender)
+	if err != nil {
+		return nil, err
+	}
+	tip, feecap, err := getCaps(conf.backend, conf.gasFeeCap)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		m        = NewAccessListMutator(rand.New(rand.NewSource(rand.Int63())))
+		involved = involvedAddresses(conf, to)
+		mutated  = *al
+	)
+	for i := rand.Intn(3); i >= 0; i-- {
+		mutated = m.Mutate(mutated, involved...)
+	}
+	// The gas used is only comparable if the execution gets the same gas as when the
+	// list was created, so half of the time the gas limit only covers the additional
+	// entries instead of being random.
+	var gas uint64
+	if conf.alChecker != nil && vmErr == "" && gasUsed != 0 && gasUsed < conf.gasLimit && containsAccessList(mutated, *al) && rand.Intn(2) == 0 {
+		gas = conf.gasLimit - accessListGas(*al) + accessListGas(mutated)
+		conf.alChecker.expect(conf.sender, conf.nonce, *al, gasUsed)
+	} else {
+		gas = randomGasLimit(conf, to, conf.code, mutated)
+	}
+	return new1559Tx(conf.nonce, to, gas, conf.chainID, tip, feecap, conf.value, conf.code, mutated), nil
+}
+
+// involvedAddresses returns the sender, the recipient and the coinbase, which are warm
+// regardless of the access list.
+func involvedAddresses(conf *txConf, to *common.Address) []common.Address {
+	involved := []common.Address{conf.sender}
+	if to != nil {
+		involved = append(involved, *to)
+	}
+	if conf.backend != nil {
+		if header, err := conf.backend.HeaderByNumber(context.Background(), nil); err == nil {
+			involved = append(involved, header.Coinbase)
+		}
+	}
+	return involved
 }
 
 func new1559Tx(nonce uint64, to *common.Address, gasLimit uint64, chainID, tip, feeCap, value *big.Int, code []byte, al types.AccessList) *types.Transaction {
@@ -189,8 +320,8 @@ func new1559Tx(nonce uint64, to *common.Address, gasLimit uint64, chainID, tip,
 	})
 }
 
-func getCaps(rpc *rpc.Client, defaultGasFeeCap *big.Int) (*big.Int, *big.Int, error) {
-	if rpc == nil {
+func getCaps(backend Backend, defaultGasFeeCap *big.Int) (*big.Int, *big.Int, error) {
+	if backend == nil {
 		tip := new(big.Int).Mul(big.NewInt(1), big.NewInt(params.GWei))
 		if defaultGasFeeCap.Cmp(tip) >= 0 {
 			feeCap := new(big.Int).Sub(defaultGasFeeCap, tip)
@@ -198,11 +329,10 @@ func getCaps(rpc *rpc.Client, defaultGasFeeCap *big.Int) (*big.Int, *big.Int, er
 		}
 		return big.NewInt(0), defaultGasFeeCap, nil
 	}
-	client := zondclient.NewClient(rpc)
-	tip, err := client.SuggestGasTipCap(context.Background())
+	tip, err := backend.SuggestGasTipCap(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
-	feeCap, err := client.SuggestGasPrice(context.Background())
+	feeCap, err := backend.SuggestGasPrice(context.Background())
 	return tip, feeCap, err
 }